@@ -3,7 +3,6 @@ package main
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
 	"io"
@@ -11,11 +10,19 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	AWAIT_ADD_DOCKET_ITEM_ID = "addtodocket"
 	AWAIT_DELITEM_ID         = "delitem"
+
+	// How long a clerk has to answer the bot when docketing.
+	CONVERSATION_TIMEOUT = 10 * time.Minute
+
+	STATUS_PASSED = "passed"
+	STATUS_FAILED = "failed"
+	STATUS_TABLED = "tabled"
 )
 
 var (
@@ -25,9 +32,9 @@ var (
 	}
 	CMD_ADD_DOCKET_ITEM = Command{
 		Handler: cmdAddDocketItem,
-		Summary: "Run the command, then provide the description in the next comment. " +
-			"Add a new item to the docket.",
-		Usage: "<motion|bill|resolution|amendment|confirmation> <@Sponsor>",
+		Summary: "Run the command, then give the title, then the text over as many messages " +
+			"as needed or as a .txt or .md file, ending with `;done`. Add a new item to the docket.",
+		Usage: "<motion|bill|resolution|amendment|confirmation> <@Sponsor> [@Cosponsor...]",
 	}
 	CMD_READ_DOCKETED_ITEM = Command{
 		Handler: cmdReadDocketedItem,
@@ -42,22 +49,22 @@ var (
 	CMD_SET_ITEM_STATUS = Command{
 		Handler: cmdSetItemStatus,
 		Summary: "Change the status of the docketed item.",
-		Usage:   "<MOTION> <STATUS>",
+		Usage:   "<MOTION> <STATUS> [--override]",
 	}
 	CMD_PASS = Command{
 		Handler: cmdPass,
 		Summary: "Pass a docketed item.",
-		Usage:   "<MOTION>",
+		Usage:   "<MOTION> [--override]",
 	}
 	CMD_FAIL = Command{
 		Handler: cmdFail,
 		Summary: "Fail a docketed item.",
-		Usage:   "<MOTION>",
+		Usage:   "<MOTION> [--override]",
 	}
 	CMD_TABLE = Command{
 		Handler: cmdTable,
 		Summary: "Table a docketed item.",
-		Usage:   "<MOTION>",
+		Usage:   "<MOTION> [--override]",
 	}
 	CMD_DELITEM = Command{
 		Handler: cmdDelitem,
@@ -68,12 +75,24 @@ var (
 	AWAIT_ADD_DOCKET_ITEM = Await{
 		Handler: awaitAddToDocket,
 		ID:      AWAIT_ADD_DOCKET_ITEM_ID,
-		AddErr:  "Someone is busy adding an item to the docket.",
+		AddErr:  "Someone is busy adding an item to the docket. They can `" + PREFIX + "cancel` it.",
+		Timeout: CONVERSATION_TIMEOUT,
+		Expired: "Adding the item to the docket timed out.",
+		Cleanup: func(channelID string) { delete(DocketItems, channelID) },
+		Claims: func(m *discordgo.MessageCreate) bool {
+			// The title and text are free-form; only the confirmation isn't.
+			item, ok := DocketItems[m.ChannelID]
+			return !ok || item.pendingStatus != PENDINGITEM_CONF || claimsAnswer(m)
+		},
 	}
 	AWAIT_DELITEM = Await{
 		Handler: awaitDelitem,
 		ID:      AWAIT_DELITEM_ID,
-		AddErr:  "Someone is busy deleting an item from the docket,",
+		AddErr:  "Someone is busy deleting an item from the docket. They can `" + PREFIX + "cancel` it.",
+		Timeout: CONVERSATION_TIMEOUT,
+		Expired: "Deleting the item from the docket timed out.",
+		Cleanup: func(channelID string) { delete(DocketDeletions, channelID) },
+		Claims:  claimsAnswer,
 	}
 )
 
@@ -82,6 +101,15 @@ type ApiError struct {
 	Error  string `json:"error"`
 }
 
+// An error reported by the API.
+type ApiStatusError struct {
+	ApiError
+}
+
+func (e ApiStatusError) Error() string {
+	return "API Error " + strconv.Itoa(e.Status) + ": " + e.ApiError.Error
+}
+
 type Ping struct {
 	Message string `json:"message"`
 }
@@ -91,15 +119,18 @@ type Docket struct {
 }
 
 const (
-	PENDINGITEM_DESC = iota
+	PENDINGITEM_TITLE = iota
+	PENDINGITEM_TEXT
 	PENDINGITEM_CONF
 )
 
 type PendingDocketItem struct {
 	motionClass   string
-	sponsorName   string
+	sponsor       Sponsor
+	cosponsors    []Sponsor
 	speakerID     string
-	name          string
+	name          string // A short title
+	text          string
 	pendingStatus int
 }
 
@@ -109,36 +140,99 @@ type PendingDeletion struct {
 }
 
 type DocketItem struct {
-	Identifier   string `json:"identifier"`
-	MotionStatus string `json:"motionStatus"`
-	MotionClass  string `json:"motionClass"`
-	ClassNumber  int    `json:"classNumber"`
-	Name         string `json:"name"`
-	Sponsor      string `json:"sponsor"`
-	Comment      string `json:"comment"`
-	Date         string `json:"date"`
+	Identifier   string    `json:"identifier"`
+	MotionStatus string    `json:"motionStatus"`
+	MotionClass  string    `json:"motionClass"`
+	ClassNumber  int       `json:"classNumber"`
+	Name         string    `json:"name"`
+	Text         string    `json:"text"`
+	Sponsor      string    `json:"sponsor"`
+	SponsorID    string    `json:"sponsorId"`
+	Comment      string    `json:"comment"`
+	Date         string    `json:"date"`
+	Cosponsors   []Sponsor `json:"cosponsors"`
+}
+
+type DocketList struct {
+	Items []DocketItem `json:"items"`
+	Page  int          `json:"page"`
+	Pages int          `json:"pages"`
+	Total int          `json:"total"`
 }
 
 var DocketItems = make(map[string]*PendingDocketItem)
 var DocketDeletions = make(map[string]*PendingDeletion)
 
-func readDocketItem(s *discordgo.Session, m *discordgo.MessageCreate, identifier string) error {
+// Fetch a docketed item from the API.
+func fetchDocketItem(s *discordgo.Session, m *discordgo.MessageCreate, identifier string) (DocketItem, error) {
 	var docketItem DocketItem
-	if err := apiRequest(s, m, "docket/read", url.Values{
+	err := apiRequest(s, m, "docket/read", url.Values{
 		"identifier": {identifier},
-	}, &docketItem); err != nil {
+	}, &docketItem)
+
+	return docketItem, err
+}
+
+// Fetch a page of docketed items matching the filters, any of which
+// may be empty.
+func listDocketItems(s *discordgo.Session, m *discordgo.MessageCreate,
+	status string, class string, sponsorID string, page int) (DocketList, error) {
+
+	var list DocketList
+	err := apiRequest(s, m, "docket/list", url.Values{
+		"status":    {status},
+		"class":     {class},
+		"sponsorid": {sponsorID},
+		"page":      {strconv.Itoa(page)},
+		"limit":     {strconv.Itoa(DOCKET_PAGE_SIZE)},
+	}, &list)
+
+	return list, err
+}
+
+// Fetch a page of docketed items whose text matches the query.
+func searchDocketItems(s *discordgo.Session, m *discordgo.MessageCreate,
+	query string, page int) (DocketList, error) {
+
+	var list DocketList
+	err := apiRequest(s, m, "docket/search", url.Values{
+		"query": {query},
+		"page":  {strconv.Itoa(page)},
+		"limit": {strconv.Itoa(DOCKET_PAGE_SIZE)},
+	}, &list)
+
+	return list, err
+}
+
+func readDocketItem(s *discordgo.Session, m *discordgo.MessageCreate, identifier string) error {
+	docketItem, err := fetchDocketItem(s, m, identifier)
+	if err != nil {
 		return err
 	}
 
-	message := fmt.Sprintf(
-		"__%s__ *(%s)*\n**Sponsor:** %s\n**Date:** %s\n\n```%s```",
-		docketItem.Identifier, docketItem.MotionStatus, docketItem.Sponsor,
-		docketItem.Date, docketItem.Name)
+	message := fmt.Sprintf("__%s__ *(%s)*\n", docketItem.Identifier, docketItem.MotionStatus)
+	if docketItem.Text != "" {
+		message += "**" + docketItem.Name + "**\n"
+	}
+	message += "**Sponsor:** " + docketItem.Sponsor + "\n"
+	if len(docketItem.Cosponsors) > 0 {
+		message += "**Cosponsors:** " + sponsorNames(docketItem.Cosponsors) + "\n"
+	}
+	message += "**Date:** " + docketItem.Date + "\n"
+
+	// Items from before titles were kept separately only have a name.
+	if docketItem.Text == "" {
+		message += fmt.Sprintf("\n```%s```", docketItem.Name)
+	}
 	if docketItem.Comment != "" {
 		message += fmt.Sprintf("\n**Comment:**\n```%s```", docketItem.Comment)
 	}
-	_, err := s.ChannelMessageSend(m.ChannelID, message)
 
+	if docketItem.Text != "" {
+		return sendBillText(s, m.ChannelID, message, docketItem.Identifier, docketItem.Text)
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, message)
 	return err
 }
 
@@ -147,15 +241,17 @@ func sendApiError(s *discordgo.Session, m *discordgo.MessageCreate, e error) err
 	return err
 }
 
-func apiRequest(s *discordgo.Session, m *discordgo.MessageCreate,
-	uri string, params url.Values, dest interface{}) error {
-
+// Make a request to the website API and decode the response into
+// dest, if given. Errors reported by the API are returned as an
+// ApiStatusError.
+func apiCall(uri string, params url.Values, dest interface{}) error {
 	params.Add("token", Auth.WebToken)
 
 	res, err := http.PostForm(Auth.BaseUri+uri, params)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 
 	var buf bytes.Buffer
 	tee := io.TeeReader(res.Body, &buf)
@@ -167,9 +263,7 @@ func apiRequest(s *discordgo.Session, m *discordgo.MessageCreate,
 	}
 
 	if apiError.Status != 200 {
-		err := errors.New("API Error " + strconv.Itoa(apiError.Status) + ": " + apiError.Error)
-		sendApiError(s, m, err)
-		return err
+		return ApiStatusError{apiError}
 	}
 
 	if dest != nil {
@@ -182,6 +276,36 @@ func apiRequest(s *discordgo.Session, m *discordgo.MessageCreate,
 	return nil
 }
 
+// Make a request to the website API on behalf of a command, reporting
+// any errors from the API to the channel.
+func apiRequest(s *discordgo.Session, m *discordgo.MessageCreate,
+	uri string, params url.Values, dest interface{}) error {
+
+	err := apiCall(uri, params, dest)
+	if _, ok := err.(ApiStatusError); ok {
+		sendApiError(s, m, err)
+	}
+
+	return err
+}
+
+// Set the status of a docketed item.
+func setItemStatus(s *discordgo.Session, m *discordgo.MessageCreate, identifier string, status string) error {
+	if err := apiRequest(s, m, "docket/status", url.Values{
+		"identifier": {identifier},
+		"status":     {status},
+	}, nil); err != nil {
+		return err
+	}
+
+	// Items the floor has disposed of are no longer before the chamber.
+	switch status {
+	case STATUS_PASSED, STATUS_FAILED, STATUS_TABLED:
+		return removeFromAgenda(m.ChannelID, identifier)
+	}
+	return nil
+}
+
 func cmdApiPing(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	var ping Ping
 	if err := apiRequest(s, m, "ping", url.Values{}, &ping); err != nil {
@@ -198,34 +322,37 @@ func cmdAddDocketItem(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return err
 	}
 
-	if ok, err := checkArgRange(s, m, 2, 2); !ok {
+	if ok, err := checkArgRange(s, m, 2, ARGS_NO_LIMIT); !ok {
 		return err
 	}
 
 	args := strings.Split(m.Content, " ")
 
-	if len(m.Mentions) != 1 {
+	class, ok, err := checkSchemaValue(s, m, Schema.Classes, "motion class", args[1])
+	if !ok {
+		return err
+	}
+
+	// The first mention is the sponsor, and the rest are cosponsors.
+	sponsors, ok := mentionedSponsors(m, args[2:])
+	if !ok {
 		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
 		return err
 	}
 
-	sponsor, err := s.User(m.Mentions[0].ID)
-	if err != nil {
+	if ok, err := addOwnedAwait(m.ChannelID, s, AWAIT_ADD_DOCKET_ITEM, m.Author.ID); !ok {
 		return err
 	}
 
 	DocketItems[m.ChannelID] = &PendingDocketItem{
-		motionClass:   args[1],
-		sponsorName:   sponsor.Username,
+		motionClass:   class,
+		sponsor:       sponsors[0],
+		cosponsors:    sponsors[1:],
 		speakerID:     m.Author.ID,
-		pendingStatus: PENDINGITEM_DESC,
-	}
-
-	if ok, err := addAwait(m.ChannelID, s, AWAIT_ADD_DOCKET_ITEM); !ok {
-		return err
+		pendingStatus: PENDINGITEM_TITLE,
 	}
 
-	_, err = s.ChannelMessageSend(m.ChannelID, "What's the description of the motion?")
+	_, err = s.ChannelMessageSend(m.ChannelID, "What's the title of the motion?")
 	return err
 }
 
@@ -237,28 +364,40 @@ func awaitAddToDocket(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	}
 
 	switch docketItem.pendingStatus {
-	case PENDINGITEM_DESC:
+	case PENDINGITEM_TITLE:
+		if m.Content == "" {
+			_, err := s.ChannelMessageSend(m.ChannelID, "The title has to be text.")
+			return err
+		}
+
 		docketItem.name = m.Content
-		docketItem.pendingStatus = PENDINGITEM_CONF
+		docketItem.pendingStatus = PENDINGITEM_TEXT
 
-		_, err := s.ChannelMessageSend(m.ChannelID, "Does this look right to you? (aye/nay)")
+		_, err := s.ChannelMessageSend(m.ChannelID, "What's the text of the motion? Send it in as many "+
+			"messages as you need, or attach a .txt or .md file, then send `"+PREFIX+"done`.")
 		return err
+	case PENDINGITEM_TEXT:
+		return addBillText(s, m, docketItem)
 	case PENDINGITEM_CONF:
-		vote, err := parseVote(m.Content)
+		vote, err := parseVote(m.GuildID, m.Content)
 
 		if err != nil {
 			_, err = s.ChannelMessageSend(m.ChannelID, "That response doesn't make sense.")
-			return nil
 			return err
 		} else if vote == Abstained {
 			_, err = s.ChannelMessageSend(m.ChannelID, "An absention doesn't make sense here.")
-			return nil
+			return err
 		} else if vote == For {
 			var docket Docket
+			cosponsors, cosponsorIDs := sponsorParams(docketItem.cosponsors)
 			if err := apiRequest(s, m, "docket/add", url.Values{
-				"motion":  {docketItem.motionClass},
-				"sponsor": {docketItem.sponsorName},
-				"name":    {docketItem.name},
+				"motion":      {docketItem.motionClass},
+				"sponsor":     {docketItem.sponsor.Name},
+				"sponsorid":   {docketItem.sponsor.ID},
+				"cosponsor":   cosponsors,
+				"cosponsorid": cosponsorIDs,
+				"name":        {docketItem.name},
+				"text":        {docketItem.text},
 			}, &docket); err != nil {
 				return err
 			}
@@ -330,24 +469,39 @@ func cmdSetItemStatus(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return err
 	}
 
-	if ok, err := checkArgRange(s, m, 2, 2); !ok {
+	if ok, err := checkArgRange(s, m, 2, 3); !ok {
 		return err
 	}
 
-	args := strings.Split(m.Content, " ")
+	args, override := splitOverride(strings.Split(m.Content, " "))
+	if len(args) != 3 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
 	identifier := args[1]
-	status := args[2]
 
-	if err := apiRequest(s, m, "docket/status", url.Values{
-		"identifier": {identifier},
-		"status":     {status},
-	}, nil); err != nil {
+	status, ok, err := checkSchemaValue(s, m, Schema.Statuses, "status", args[2])
+	if !ok {
+		return err
+	}
+
+	if ok, err := checkStatusChange(s, m, identifier, status, override); !ok {
+		return err
+	}
+
+	if err := setItemStatus(s, m, identifier, status); err != nil {
 		return err
 	}
 
 	message := identifier + " is now considered a(n) " + status + " matter."
-	_, err := s.ChannelMessageSend(m.ChannelID, message)
-	return err
+	if _, err = s.ChannelMessageSend(m.ChannelID, message); err != nil {
+		return err
+	}
+
+	if status == STATUS_PASSED {
+		return routeItem(s, m, identifier)
+	}
+	return nil
 }
 
 func cmdPass(s *discordgo.Session, m *discordgo.MessageCreate) error {
@@ -355,23 +509,31 @@ func cmdPass(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return err
 	}
 
-	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+	if ok, err := checkArgRange(s, m, 1, 2); !ok {
 		return err
 	}
 
-	args := strings.Split(m.Content, " ")
+	args, override := splitOverride(strings.Split(m.Content, " "))
+	if len(args) != 2 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
 	identifier := args[1]
 
-	if err := apiRequest(s, m, "docket/status", url.Values{
-		"identifier": {identifier},
-		"status":     {"passed"},
-	}, nil); err != nil {
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_PASSED, override); !ok {
+		return err
+	}
+
+	if err := setItemStatus(s, m, identifier, STATUS_PASSED); err != nil {
 		return err
 	}
 
 	message := identifier + " is now considered passed."
-	_, err := s.ChannelMessageSend(m.ChannelID, message)
-	return err
+	if _, err := s.ChannelMessageSend(m.ChannelID, message); err != nil {
+		return err
+	}
+
+	return routeItem(s, m, identifier)
 }
 
 func cmdFail(s *discordgo.Session, m *discordgo.MessageCreate) error {
@@ -379,17 +541,22 @@ func cmdFail(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return err
 	}
 
-	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+	if ok, err := checkArgRange(s, m, 1, 2); !ok {
 		return err
 	}
 
-	args := strings.Split(m.Content, " ")
+	args, override := splitOverride(strings.Split(m.Content, " "))
+	if len(args) != 2 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
 	identifier := args[1]
 
-	if err := apiRequest(s, m, "docket/status", url.Values{
-		"identifier": {identifier},
-		"status":     {"failed"},
-	}, nil); err != nil {
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_FAILED, override); !ok {
+		return err
+	}
+
+	if err := setItemStatus(s, m, identifier, STATUS_FAILED); err != nil {
 		return err
 	}
 
@@ -403,17 +570,22 @@ func cmdTable(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return err
 	}
 
-	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+	if ok, err := checkArgRange(s, m, 1, 2); !ok {
 		return err
 	}
 
-	args := strings.Split(m.Content, " ")
+	args, override := splitOverride(strings.Split(m.Content, " "))
+	if len(args) != 2 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
 	identifier := args[1]
 
-	if err := apiRequest(s, m, "docket/status", url.Values{
-		"identifier": {identifier},
-		"status":     {"tabled"},
-	}, nil); err != nil {
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_TABLED, override); !ok {
+		return err
+	}
+
+	if err := setItemStatus(s, m, identifier, STATUS_TABLED); err != nil {
 		return err
 	}
 
@@ -438,7 +610,7 @@ func cmdDelitem(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return err
 	}
 
-	if ok, err := addAwait(m.ChannelID, s, AWAIT_DELITEM); !ok {
+	if ok, err := addOwnedAwait(m.ChannelID, s, AWAIT_DELITEM, m.Author.ID); !ok {
 		return err
 	}
 
@@ -458,7 +630,7 @@ func awaitDelitem(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return nil
 	}
 
-	vote, err := parseVote(m.Content)
+	vote, err := parseVote(m.GuildID, m.Content)
 	if err != nil {
 		_, err := s.ChannelMessageSend(m.ChannelID, "That response doesn't make sense.")
 		return err
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_QUORUM_CALL  = 5
+	AWAIT_QUORUM_CALL_ID = "quorumcall"
+)
+
+var (
+	CMD_QUORUMCALL = Command{
+		Handler: cmdQuorumCall,
+		Summary: "Ask chamber members to answer \"here\" to check whether quorum is present",
+		Usage:   "[minutes]",
+	}
+	CMD_ATTENDANCE = Command{
+		Handler: cmdAttendance,
+		Summary: "Show attendance statistics from quorum calls",
+		Usage:   "[member]",
+	}
+
+	AWAIT_QUORUM_CALL = Await{
+		Handler: awaitQuorumCall,
+		ID:      AWAIT_QUORUM_CALL_ID,
+		AddErr:  "A quorum call is already in progress",
+	}
+
+	ATTENDANCE_ANSWERS = []string{
+		"here",
+		"present",
+	}
+)
+
+type QuorumCall struct {
+	record  AttendanceRecord
+	members []string        // List of UserID's expected to answer
+	present map[string]bool // Set of UserID's that answered
+}
+
+type AttendanceRecord struct {
+	Time    time.Time `json:"time"`
+	Session time.Time `json:"session"` // When the chamber was convened, if it was in session
+	Present []string  `json:"present"`
+	Absent  []string  `json:"absent"`
+	Excused []string  `json:"excused"`
+	Quorum  int       `json:"quorum"`
+}
+
+var QuorumCalls = make(map[string]*QuorumCall)
+
+func saveAttendance() error {
+	file, err := os.Create(ATTENDANCE_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(Attendance)
+
+	return file.Close()
+}
+
+// End the quorum call, record its attendance and report whether
+// quorum is present.
+func stopQuorumCall(s *discordgo.Session, channelID string) error {
+	if ok := removeAwait(channelID, AWAIT_QUORUM_CALL_ID); !ok {
+		return nil
+	}
+
+	call := QuorumCalls[channelID]
+	delete(QuorumCalls, channelID)
+
+	record := call.record
+	for _, userID := range call.members {
+		if call.present[userID] {
+			record.Present = append(record.Present, userID)
+		} else {
+			record.Absent = append(record.Absent, userID)
+		}
+	}
+
+	Attendance[channelID] = append(Attendance[channelID], record)
+	if err := saveAttendance(); err != nil {
+		return err
+	}
+
+	response := fmt.Sprintf("**Quorum is %d; %d members are present.** ", record.Quorum, len(record.Present))
+	if len(record.Present) >= record.Quorum {
+		response += "A quorum is present."
+	} else {
+		response += "A quorum is not present."
+	}
+
+	if len(record.Absent) > 0 {
+		var absent []string
+		for _, userID := range record.Absent {
+			user, err := s.User(userID)
+			if err != nil {
+				return err
+			}
+			absent = append(absent, address(channelID, user))
+		}
+		response += "\n**Absent:** " + strings.Join(absent, ", ")
+	}
+
+	_, err := s.ChannelMessageSend(channelID, response)
+	return err
+}
+
+func cmdQuorumCall(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 0, 1); !ok {
+		return err
+	}
+
+	duration := DEFAULT_QUORUM_CALL
+	args := strings.Split(m.Content, " ")
+	if len(args) == 2 {
+		var err error
+
+		duration, err = strconv.Atoi(args[1])
+		if err != nil || duration <= 0 {
+			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+	}
+
+	// Answers such as "present" would also count as votes.
+	if _, ok := findAwait(m.ChannelID, AWAIT_CALL_ID); ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "A roll-call vote is in progress.")
+		return err
+	}
+
+	channel, err := s.State.Channel(m.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	members, err := getChamberMembers(s, channel)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := addAwait(m.ChannelID, s, AWAIT_QUORUM_CALL); !ok {
+		return err
+	}
+
+	pruneLeaves(m.ChannelID)
+	call := &QuorumCall{
+		record: AttendanceRecord{
+			Time:    time.Now(),
+			Session: Sessions[m.ChannelID],
+		},
+		present: make(map[string]bool),
+	}
+
+	for _, member := range members {
+		if onLeave(m.ChannelID, member.User.ID, time.Now()) {
+			call.record.Excused = append(call.record.Excused, member.User.ID)
+		} else {
+			call.members = append(call.members, member.User.ID)
+		}
+	}
+	call.record.Quorum = quorumOf(len(call.members))
+	QuorumCalls[m.ChannelID] = call
+
+	go func() {
+		time.Sleep(time.Duration(duration) * time.Minute)
+
+		CommandMutex.Lock()
+		defer CommandMutex.Unlock()
+
+		if QuorumCalls[m.ChannelID] == call {
+			stopQuorumCall(s, m.ChannelID)
+		}
+	}()
+
+	return ping(s, m, "**Quorum call.** Please answer `here` within "+
+		formatMinutes(time.Duration(duration)*time.Minute)+".")
+}
+
+func awaitQuorumCall(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	call, ok := QuorumCalls[m.ChannelID]
+	if !ok {
+		// This shouldn't happen; remove our await.
+		removeAwait(m.ChannelID, AWAIT_QUORUM_CALL_ID)
+		return nil
+	}
+
+	expected := false
+	for _, userID := range call.members {
+		if userID == m.Author.ID {
+			expected = true
+			break
+		}
+	}
+
+	fields := strings.Fields(strings.ToLower(m.Content))
+	if !expected || len(fields) == 0 {
+		return nil
+	}
+
+	for _, answer := range ATTENDANCE_ANSWERS {
+		if fields[0] == answer {
+			call.present[m.Author.ID] = true
+
+			if err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK); err != nil {
+				return err
+			}
+
+			if len(call.present) == len(call.members) {
+				// Everyone is here; no need to wait.
+				return stopQuorumCall(s, m.ChannelID)
+			}
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func cmdAttendance(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 0, 1); !ok {
+		return err
+	}
+
+	records, ok := Attendance[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "No quorum calls have been recorded here.")
+		return err
+	}
+
+	type tally struct {
+		present, absent, excused int
+	}
+	tallies := make(map[string]*tally)
+	tallyOf := func(userID string) *tally {
+		if tallies[userID] == nil {
+			tallies[userID] = &tally{}
+		}
+		return tallies[userID]
+	}
+
+	for _, record := range records {
+		for _, userID := range record.Present {
+			tallyOf(userID).present++
+		}
+		for _, userID := range record.Absent {
+			tallyOf(userID).absent++
+		}
+		for _, userID := range record.Excused {
+			tallyOf(userID).excused++
+		}
+	}
+
+	var userIDs []string
+	if len(m.Mentions) == 1 {
+		userIDs = []string{m.Mentions[0].ID}
+	} else {
+		for userID := range tallies {
+			userIDs = append(userIDs, userID)
+		}
+		sort.Strings(userIDs)
+	}
+
+	response := fmt.Sprintf("*Attendance over %d quorum calls:*\n", len(records))
+	for _, userID := range userIDs {
+		t := tallyOf(userID)
+
+		user, err := s.User(userID)
+		if err != nil {
+			return err
+		}
+
+		rate := 0.0
+		if t.present+t.absent > 0 {
+			rate = 100 * float64(t.present) / float64(t.present+t.absent)
+		}
+
+		response += fmt.Sprintf("\n%s: present %d, absent %d, excused %d (%.0f%%)",
+			address(m.ChannelID, user), t.present, t.absent, t.excused, rate)
+	}
+
+	return sendSplit(s, m.ChannelID, response)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/bwmarrin/discordgo"
 	"strings"
+	"time"
 )
 
 var (
@@ -21,12 +22,19 @@ var (
 	}
 )
 
+// Map from channel ID to when its chamber was convened, if in session.
+var Sessions = make(map[string]time.Time)
+
 func cmdConvene(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	Sessions[m.ChannelID] = time.Now()
+
 	_, err := s.ChannelMessageSend(m.ChannelID, "**The chamber is called to order.**")
 	return err
 }
 
 func cmdDismiss(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	delete(Sessions, m.ChannelID)
+
 	args := strings.Split(m.Content, " ")
 	var err error
 
@@ -41,6 +49,8 @@ func cmdDismiss(s *discordgo.Session, m *discordgo.MessageCreate) error {
 }
 
 func cmdAdjournSineDie(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	delete(Sessions, m.ChannelID)
+
 	_, err := s.ChannelMessageSend(m.ChannelID, "**The chamber is adjourned *sine die*.**")
 	return err
 }
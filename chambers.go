@@ -201,8 +201,7 @@ func list(s *discordgo.Session, m *discordgo.MessageCreate) error {
 
 	message := ch.Mention() + " members:\n\n"
 	for _, member := range members {
-		user := member.User
-		message += user.Username + "#" + user.Discriminator + "\n"
+		message += address(ch.ID, member.User) + "\n"
 	}
 
 	_, err = s.ChannelMessageSend(m.ChannelID, message)
@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"os"
 	"os/signal"
@@ -18,10 +20,20 @@ import (
 const (
 	PREFIX = ";"
 
-	CHAMBER_PATH = "chambers.json"
-	AUTH_PATH    = "auth.json"
-	CLERK_PATH   = "clerks.json"
-	CANNED_PATH  = "canned.json"
+	CHAMBER_PATH    = "chambers.json"
+	AUTH_PATH       = "auth.json"
+	CLERK_PATH      = "clerks.json"
+	CANNED_PATH     = "canned.json"
+	OPTOUT_PATH     = "dm-optouts.json"
+	PROFILE_PATH    = "profiles.json"
+	VOCAB_PATH      = "vocabulary.json"
+	LEAVE_PATH      = "leaves.json"
+	ATTENDANCE_PATH = "attendance.json"
+	ARCHIVE_PATH    = "rollcalls.json"
+	SCHEMA_PATH     = "docket-schema.json"
+	EXECUTIVE_PATH  = "executive.json"
+	DESK_PATH       = "desk.json"
+	COMMITTEE_PATH  = "committees.json"
 
 	REACT_OK = "\u2705"
 
@@ -33,6 +45,8 @@ const (
 	MSG_NOT_A_CLERK          = "You are not an approved clerk."
 
 	ARGS_NO_LIMIT = -1
+
+	MESSAGE_MAX = 2000 // Discord's limit on the length of a message
 )
 
 // Provided by auth.json
@@ -50,6 +64,22 @@ type Await struct {
 	Handler Handler
 	ID      string // Identifies the type of await it is.
 	AddErr  string // Error to say if an await tries to replace this one
+
+	// How long the await can go without hearing from its owner before it
+	// expires, and what to say when it does. Zero never expires.
+	Timeout time.Duration
+	Expired string
+
+	// Called with the channel ID whenever the await is removed.
+	Cleanup func(channelID string)
+
+	// Whether a message from the owner is the await's to answer. Messages
+	// it doesn't claim go on to the awaits without an owner. Unset claims
+	// every message from the owner.
+	Claims func(m *discordgo.MessageCreate) bool
+
+	owner   string // The user who started the await, if it can be cancelled
+	expires time.Time
 }
 
 type Command struct {
@@ -62,6 +92,15 @@ type Chamber struct {
 	MemberRole  string `json:"member"`
 	SpeakerRole string `json:"speaker"`
 	ApiName     string `json:"apiname"`
+	Debate      int    `json:"debate"`   // Minutes of debate on a seconded motion
+	Speaking    int    `json:"speaking"` // Minutes each member may hold the floor
+	Title       string `json:"title"`    // Default title for members without one
+	VoteChange  string `json:"votechange"`
+	PassTo      string `json:"passto"` // Chamber channel ID or ROUTE_EXECUTIVE that passed items go to
+	Parent      string `json:"parent"` // Channel ID of the chamber this committee reports to
+	Rules       string `json:"rules"`  // Markdown rules book, by default RULES_DIR/<channel ID>.md
+
+	Reminders []float64 `json:"reminders"` // Fractions of a timed vote after which to remind non-voters
 }
 
 var (
@@ -70,15 +109,27 @@ var (
 		Summary: "Show a list of all commands available or displays help for a specific command",
 		Usage:   "[command name]",
 	}
+	CMD_CANCEL = Command{
+		Handler: cmdCancel,
+		Summary: "Cancel a request the bot is waiting on in this channel, such as adding a docket item",
+		Usage:   "[addtodocket|delitem|edititem]",
+	}
 )
 
 // State
 var Commands = make(map[string]Command)
-var Awaits = make(map[string]Await)
+var Awaits = make(map[string][]*Await)
 var Chambers = make(map[string]Chamber)
 var Canned = make(map[string]string)
+var Profiles = make(map[string]Profile)
+var Vocabularies = make(map[string]Vocabulary)
+var Leaves = make(map[string][]Leave)
+var Attendance = make(map[string][]AttendanceRecord)
+var Archive = make(map[string][]RollCallRecord)
 var Clerks []string
+var DMOptOuts []string
 var Auth AuthSettings
+var Executive ExecutiveSettings
 var CommandMutex = &sync.Mutex{}
 
 // Add a command to the bot.
@@ -87,36 +138,138 @@ func addCommand(name string, cmd Command) {
 }
 
 // Attempt to attach an await to the channel. Return whether
-// successful.
+// successful. Awaits with different IDs can share a channel.
 func addAwait(channelID string, s *discordgo.Session, await Await) (bool, error) {
-	if prev, exists := Awaits[channelID]; exists {
-		// Await already exists for channel; handle appropriately.
+	return addOwnedAwait(channelID, s, await, "")
+}
 
-		_, err := s.ChannelMessageSend(channelID, prev.AddErr)
-		return false, err
+// Like addAwait, but only messages from the owner reach the await, and
+// the owner, or a channel manager, can cancel it. Each user can own one
+// await per channel.
+func addOwnedAwait(channelID string, s *discordgo.Session, await Await, ownerID string) (bool, error) {
+	for _, prev := range Awaits[channelID] {
+		if prev.ID == await.ID {
+			// Await already exists for channel; handle appropriately.
+
+			_, err := s.ChannelMessageSend(channelID, prev.AddErr)
+			return false, err
+		} else if ownerID != "" && prev.owner == ownerID {
+			_, err := s.ChannelMessageSend(channelID, "You're already busy with something here. "+
+				"Finish it or `"+PREFIX+"cancel` it first.")
+			return false, err
+		}
 	}
 
-	Awaits[channelID] = await
+	added := await
+	added.owner = ownerID
+	Awaits[channelID] = append(Awaits[channelID], &added)
 	log.Println("Added await '" + await.ID + "'")
+
+	if added.Timeout > 0 {
+		added.expires = time.Now().Add(added.Timeout)
+		go expireAwait(s, channelID, &added, added.expires)
+	}
 	return true, nil
 }
 
-// Remove any attached await from the channel if the id
-// matches. Return if removed.
+// Return the channel's await with the id.
+func findAwait(channelID string, id string) (*Await, bool) {
+	for _, await := range Awaits[channelID] {
+		if await.ID == id {
+			return await, true
+		}
+	}
+
+	return nil, false
+}
+
+// Return the awaits that a message should go to. Messages from the
+// owner of an await go to that await alone if it claims them; all other
+// messages go to every await without an owner, in the order they were
+// added.
+func awaitsFor(m *discordgo.MessageCreate) []*Await {
+	var awaits []*Await
+
+	for _, await := range Awaits[m.ChannelID] {
+		if await.owner == m.Author.ID && (await.Claims == nil || await.Claims(m)) {
+			return []*Await{await}
+		} else if await.owner == "" {
+			awaits = append(awaits, await)
+		}
+	}
+
+	return awaits
+}
+
+// Claim only answers to a yes or no question, so the owner can still
+// take part in other awaits, such as voting, while one is asked.
+func claimsAnswer(m *discordgo.MessageCreate) bool {
+	_, err := parseVote(m.GuildID, m.Content)
+	return err == nil
+}
+
+// Push back the expiry of the await, if it has one.
+func (a *Await) touch() {
+	if a.Timeout > 0 {
+		a.expires = time.Now().Add(a.Timeout)
+	}
+}
+
+// Remove the await once it has gone unanswered past its expiry.
+// expires is the await's expiry when the goroutine starts; touch moves
+// it under CommandMutex, so it's only read again with the lock held.
+func expireAwait(s *discordgo.Session, channelID string, await *Await, expires time.Time) {
+	for {
+		time.Sleep(time.Until(expires))
+
+		CommandMutex.Lock()
+		if current, ok := findAwait(channelID, await.ID); !ok || current != await {
+			// The await already ended.
+			CommandMutex.Unlock()
+			return
+		}
+
+		if time.Now().Before(await.expires) {
+			// The await was touched while we slept.
+			expires = await.expires
+			CommandMutex.Unlock()
+			continue
+		}
+
+		removeAwait(channelID, await.ID)
+		if _, err := s.ChannelMessageSend(channelID, await.Expired); err != nil {
+			log.Println("Error expiring await:", err)
+		}
+		CommandMutex.Unlock()
+		return
+	}
+}
+
+// Remove the await with the id from the channel. Return if removed.
 func removeAwait(channelID string, id string) bool {
-	await, exists := Awaits[channelID]
-
-	if !exists {
-		// No await exists.
-		return false
-	} else if await.ID != id {
-		// ID doesn't match.
-		return false
-	} else {
-		delete(Awaits, channelID)
+	awaits := Awaits[channelID]
+
+	for i, await := range awaits {
+		if await.ID != id {
+			continue
+		}
+
+		awaits = append(awaits[:i:i], awaits[i+1:]...)
+		if len(awaits) == 0 {
+			delete(Awaits, channelID)
+		} else {
+			Awaits[channelID] = awaits
+		}
 		log.Println("Removed await '" + await.ID + "'")
+
+		if await.Cleanup != nil {
+			await.Cleanup(channelID)
+		}
 		return true
 	}
+
+	// No await with the ID exists.
+	return false
 }
 
 // Decode the given JSON file and store it in the appropriate data
@@ -134,6 +287,43 @@ func loadSettings(dest interface{}, src string) error {
 	return file.Close()
 }
 
+// Like loadSettings, but leave dest untouched if the file doesn't
+// exist yet.
+func loadOptionalSettings(dest interface{}, src string) error {
+	if err := loadSettings(dest, src); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Send the message to the channel, split at line breaks into as many
+// messages as Discord's length limit needs.
+func sendSplit(s *discordgo.Session, channelID string, message string) error {
+	for message != "" {
+		chunk := message
+		if len(chunk) > MESSAGE_MAX {
+			cut := MESSAGE_MAX
+			if i := strings.LastIndex(chunk[:cut], "\n"); i > 0 {
+				cut = i
+			} else {
+				// A single long line; don't cut a character in half.
+				for !utf8.RuneStart(chunk[cut]) {
+					cut--
+				}
+			}
+			chunk = chunk[:cut]
+		}
+
+		if _, err := s.ChannelMessageSend(channelID, chunk); err != nil {
+			return err
+		}
+		message = strings.TrimPrefix(message[len(chunk):], "\n")
+	}
+
+	return nil
+}
+
 // Return whether the arguments are within range, and send an error
 // message if it isn't.
 func checkArgRange(s *discordgo.Session, m *discordgo.MessageCreate, argMin int, argMax int) (bool, error) {
@@ -168,46 +358,152 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if err := loadOptionalSettings(&DMOptOuts, OPTOUT_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadOptionalSettings(&Profiles, PROFILE_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadOptionalSettings(&Vocabularies, VOCAB_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadOptionalSettings(&Leaves, LEAVE_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadOptionalSettings(&Attendance, ATTENDANCE_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadOptionalSettings(&Archive, ARCHIVE_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadOptionalSettings(&Executive, EXECUTIVE_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadOptionalSettings(&Desk, DESK_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadOptionalSettings(&Agendas, COMMITTEE_PATH); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadRuleBooks(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := loadSchema(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Setup the bot.
 	dg, err := discordgo.New("Bot " + Auth.Token)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Run the export subcommand instead of the bot if asked to.
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(dg, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	dg.AddHandler(messageCreate)
+	dg.AddHandler(messageUpdate)
+	dg.AddHandler(messageDelete)
+	dg.AddHandler(messageReactionAdd)
+	dg.AddHandler(messageReactionRemove)
 
 	// Add commands
 	addCommand("help", CMD_HELP)
+	addCommand("cancel", CMD_CANCEL)
 
 	addCommand("addchamber", CMD_ADD_CHAMBER)
 	addCommand("removechamber", CMD_REMOVE_CHAMBER)
 	addCommand("list", CMD_LIST)
 	addCommand("add", CMD_ADD)
 	addCommand("remove", CMD_REMOVE)
+	addCommand("leave", CMD_LEAVE)
+	addCommand("endleave", CMD_ENDLEAVE)
+	addCommand("leaves", CMD_LEAVES)
+	addCommand("settitle", CMD_SETTITLE)
+	addCommand("setdefaulttitle", CMD_SETDEFAULTTITLE)
 
 	addCommand("ping", CMD_PING)
 	addCommand("unanimous", CMD_UNANIMOUS)
+	addCommand("withdraw", CMD_WITHDRAW)
+	addCommand("escalate", CMD_ESCALATE)
+	addCommand("cloture", CMD_CLOTURE)
+	addCommand("discharge", CMD_DISCHARGE)
+	addCommand("passage", CMD_PASSAGE)
 
 	addCommand("convene", CMD_CONVENE)
 	addCommand("dismiss", CMD_DISMISS)
 	addCommand("adjournsinedie", CMD_ADJOURNSINEDIE)
 
+	addCommand("move", CMD_MOVE)
+	addCommand("second", CMD_SECOND)
+	addCommand("floor", CMD_FLOOR)
+	addCommand("yield", CMD_YIELD)
+	addCommand("previousquestion", CMD_PREVIOUSQUESTION)
+	addCommand("setdebate", CMD_SETDEBATE)
+
+	addCommand("quorumcall", CMD_QUORUMCALL)
+	addCommand("attendance", CMD_ATTENDANCE)
+
 	addCommand("call", CMD_CALL)
 	addCommand("endvoting", CMD_ENDVOTING)
 	addCommand("resumevoting", CMD_RESUMEVOTING)
 	addCommand("cast", CMD_CAST)
 	addCommand("getvotes", CMD_GETVOTES)
+	addCommand("stats", CMD_STATS)
+	addCommand("archive", CMD_ARCHIVE)
+	addCommand("export", CMD_EXPORT)
 	addCommand("setvotes", CMD_SETVOTES)
+	addCommand("votechange", CMD_VOTECHANGE)
+	addCommand("votewords", CMD_VOTEWORDS)
+	addCommand("setvotewords", CMD_SETVOTEWORDS)
+	addCommand("setvotereact", CMD_SETVOTEREACT)
+	addCommand("setreminders", CMD_SETREMINDERS)
+	addCommand("reminders", CMD_REMINDERS)
 
 	addCommand("apiping", CMD_APIPING)
 	addCommand("addtodocket", CMD_ADD_DOCKET_ITEM)
 	addCommand("readitem", CMD_READ_DOCKETED_ITEM)
+	addCommand("docket", CMD_DOCKET)
+	addCommand("docketsearch", CMD_DOCKETSEARCH)
 	addCommand("commentitem", CMD_COMMENT_DOCKETED_ITEM)
 	addCommand("setstatus", CMD_SET_ITEM_STATUS)
 	addCommand("pass", CMD_PASS)
+	addCommand("route", CMD_ROUTE)
+	addCommand("setparent", CMD_SETPARENT)
+	addCommand("refer", CMD_REFER)
+	addCommand("hearing", CMD_HEARING)
+	addCommand("report", CMD_REPORT)
+	addCommand("agenda", CMD_AGENDA)
+	addCommand("rule", CMD_RULE)
+	addCommand("rules", CMD_RULES)
+	addCommand("pointoforder", CMD_POINT_OF_ORDER)
+	addCommand("ruling", CMD_RULING)
+	addCommand("setexecutive", CMD_SETEXECUTIVE)
+	addCommand("desk", CMD_DESK)
+	addCommand("sign", CMD_SIGN)
+	addCommand("veto", CMD_VETO)
+	addCommand("override", CMD_OVERRIDE)
 	addCommand("fail", CMD_FAIL)
 	addCommand("table", CMD_TABLE)
 	addCommand("delitem", CMD_DELITEM)
+	addCommand("done", CMD_DONE)
+	addCommand("edititem", CMD_EDITITEM)
+	addCommand("cosponsor", CMD_COSPONSOR)
 
 	addCommand("addclerk", CMD_ADDCLERK)
 	addCommand("removeclerk", CMD_REMOVECLERK)
@@ -218,6 +514,7 @@ func main() {
 	if err = dg.Open(); err != nil {
 		log.Fatal("error opening connection,", err)
 	}
+	scheduleDesk(dg)
 
 	// Wait here until an interruption signal is received
 	fmt.Println("Committee clerk is now running. Press CTRL-C to exit.")
@@ -274,8 +571,16 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		if err := cmd.Handler(s, m); err != nil {
 			log.Println("Error processing command:", err)
 		}
-	} else if await, ok := Awaits[m.ChannelID]; ok {
-		// Not a command; redirect message to channel's await if it exists.
+	} else {
+		// Not a command; redirect message to the channel's awaits.
+
+		CommandMutex.Lock()
+		defer CommandMutex.Unlock()
+
+		awaits := awaitsFor(m)
+		if len(awaits) == 0 {
+			return
+		}
 
 		if ch, err := s.Channel(m.ChannelID); err == nil {
 			log.Println(m.Author, "triggered await in #"+ch.Name)
@@ -283,15 +588,74 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 			log.Println(m.Author, "triggered await in channel", m.ChannelID)
 		}
 
-		CommandMutex.Lock()
-		defer CommandMutex.Unlock()
+		for _, await := range awaits {
+			// An earlier await may have ended this one.
+			if current, ok := findAwait(m.ChannelID, await.ID); !ok || current != await {
+				continue
+			}
 
-		if err := await.Handler(s, m); err != nil {
-			log.Println("Error for await:", err)
+			if m.Author.ID == await.owner {
+				await.touch()
+			}
+
+			if err := await.Handler(s, m); err != nil {
+				log.Println("Error for await '"+await.ID+"':", err)
+			}
 		}
+	}
+}
 
-		return
+func cmdCancel(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 0, 1); !ok {
+		return err
+	}
+
+	// Find the named request, or else the author's own, or else the only
+	// one in the channel.
+	args := strings.Split(m.Content, " ")
+	var cancellable []*Await
+	for _, await := range Awaits[m.ChannelID] {
+		if await.owner == "" {
+			continue
+		}
+
+		if len(args) == 2 {
+			if await.ID == args[1] {
+				cancellable = append(cancellable, await)
+			}
+		} else if await.owner == m.Author.ID {
+			cancellable = []*Await{await}
+			break
+		} else {
+			cancellable = append(cancellable, await)
+		}
+	}
+
+	if len(cancellable) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "There's nothing to cancel.")
+		return err
+	} else if len(cancellable) > 1 {
+		var ids []string
+		for _, await := range cancellable {
+			ids = append(ids, await.ID)
+		}
+
+		_, err := s.ChannelMessageSend(m.ChannelID, "Say which to cancel: "+strings.Join(ids, ", ")+".")
+		return err
+	}
+
+	await := cancellable[0]
+
+	if m.Author.ID != await.owner {
+		if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+			return err
+		}
 	}
+
+	removeAwait(m.ChannelID, await.ID)
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
 }
 
 func help(s *discordgo.Session, m *discordgo.MessageCreate) error {
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	STATUS_REPORTED = "reported"
+
+	REPORT_FAVORABLE   = "favorable"
+	REPORT_UNFAVORABLE = "unfavorable"
+
+	HEARING_TIME_FORMAT = "2006-01-02 15:04"
+)
+
+var (
+	CMD_SETPARENT = Command{
+		Handler: cmdSetParent,
+		Summary: "Make this chamber a committee of another chamber, or not a committee",
+		Usage:   "<#chamber|none>",
+	}
+	CMD_REFER = Command{
+		Handler: cmdRefer,
+		Summary: "Refer a docketed item to one of this chamber's committees",
+		Usage:   "<MOTION> <#committee>",
+	}
+	CMD_HEARING = Command{
+		Handler: cmdHearing,
+		Summary: "Schedule a committee hearing on a referred item, in UTC",
+		Usage:   "<MOTION> <YYYY-MM-DD> <HH:MM> [@witness...]",
+	}
+	CMD_REPORT = Command{
+		Handler: cmdReport,
+		Summary: "Report an item back to the parent chamber with the committee's roll call on it",
+		Usage:   "<MOTION> <favorable|unfavorable> [roll call number from archive]",
+	}
+	CMD_AGENDA = Command{
+		Handler: cmdAgenda,
+		Summary: "List the items before this chamber and any scheduled hearings",
+	}
+)
+
+// An item before a chamber, referred or reported to it.
+type AgendaItem struct {
+	Identifier string    `json:"identifier"`
+	From       string    `json:"from"` // Channel ID of the chamber that sent the item
+	Added      time.Time `json:"added"`
+	Report     string    `json:"report,omitempty"` // The committee's recommendation, if reported
+	Vote       string    `json:"vote,omitempty"`   // Summary of the committee's roll call
+}
+
+type Hearing struct {
+	Identifier string    `json:"identifier"`
+	Time       time.Time `json:"time"`
+	Witnesses  []string  `json:"witnesses"` // UserIDs
+}
+
+// The items before a chamber, and a committee's hearings.
+type Agenda struct {
+	Items    []AgendaItem `json:"items"`
+	Hearings []Hearing    `json:"hearings"`
+}
+
+var Agendas = make(map[string]Agenda)
+
+func saveAgendas() error {
+	file, err := os.Create(COMMITTEE_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(Agendas)
+
+	return file.Close()
+}
+
+// Return the position of the item on the chamber's agenda, or -1.
+func agendaIndex(channelID string, identifier string) int {
+	for i, item := range Agendas[channelID].Items {
+		if item.Identifier == identifier {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Put the item on the chamber's agenda, replacing any earlier entry.
+func addToAgenda(channelID string, item AgendaItem) error {
+	agenda := Agendas[channelID]
+	if i := agendaIndex(channelID, item.Identifier); i >= 0 {
+		agenda.Items[i] = item
+	} else {
+		agenda.Items = append(agenda.Items, item)
+	}
+
+	Agendas[channelID] = agenda
+	return saveAgendas()
+}
+
+// Take the item and its hearings off the chamber's agenda.
+func removeFromAgenda(channelID string, identifier string) error {
+	agenda, ok := Agendas[channelID]
+	if !ok {
+		return nil
+	}
+
+	var items []AgendaItem
+	for _, item := range agenda.Items {
+		if item.Identifier != identifier {
+			items = append(items, item)
+		}
+	}
+
+	var hearings []Hearing
+	for _, hearing := range agenda.Hearings {
+		if hearing.Identifier != identifier {
+			hearings = append(hearings, hearing)
+		}
+	}
+
+	if len(items) == len(agenda.Items) && len(hearings) == len(agenda.Hearings) {
+		return nil
+	}
+
+	agenda.Items, agenda.Hearings = items, hearings
+	Agendas[channelID] = agenda
+	return saveAgendas()
+}
+
+// Return whether the roll call's question names the item.
+func (r RollCallRecord) concerns(identifier string) bool {
+	words := strings.FieldsFunc(r.Motion, func(c rune) bool {
+		return unicode.IsSpace(c) || strings.ContainsRune(",;:()[]\"'", c)
+	})
+	for _, word := range words {
+		if strings.EqualFold(strings.TrimSuffix(word, "."), identifier) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return the chamber's most recent archived roll call on the item.
+func itemRollCall(channelID string, identifier string) (RollCallRecord, bool) {
+	records := Archive[channelID]
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].concerns(identifier) {
+			return records[i], true
+		}
+	}
+
+	return RollCallRecord{}, false
+}
+
+// Return whether the channel is a committee, and send a message if it
+// isn't.
+func checkIsCommittee(s *discordgo.Session, m *discordgo.MessageCreate) (bool, error) {
+	if Chambers[m.ChannelID].Parent != "" {
+		return true, nil
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, "This chamber isn't a committee.")
+	return false, err
+}
+
+// Return whether the item is before the chamber, and send a message if
+// it isn't.
+func checkOnAgenda(s *discordgo.Session, m *discordgo.MessageCreate, identifier string) (bool, error) {
+	if agendaIndex(m.ChannelID, identifier) >= 0 {
+		return true, nil
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, identifier+" isn't before this committee.")
+	return false, err
+}
+
+func cmdSetParent(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	chamber, ok := Chambers[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	if args[1] == ROUTE_NONE {
+		chamber.Parent = ""
+	} else {
+		channelID, ok := mentionedChannel(args[1])
+		if !ok || !isChamber(channelID) || channelID == m.ChannelID {
+			_, err := s.ChannelMessageSend(m.ChannelID, "The parent has to be another chamber.")
+			return err
+		}
+		chamber.Parent = channelID
+	}
+
+	Chambers[m.ChannelID] = chamber
+	if err := saveChambers(); err != nil {
+		return err
+	}
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
+
+func cmdRefer(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 2, 2); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+
+	committeeID, ok := mentionedChannel(args[2])
+	committee, isChamber := Chambers[committeeID]
+	if !ok || !isChamber || committee.Parent != m.ChannelID {
+		_, err := s.ChannelMessageSend(m.ChannelID, args[2]+" isn't a committee of this chamber.")
+		return err
+	}
+
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_REFERRED, false); !ok {
+		return err
+	}
+
+	item, err := fetchDocketItem(s, m, identifier)
+	if err != nil {
+		return err
+	}
+
+	var docket Docket
+	if err := apiRequest(s, m, "docket/refer", url.Values{
+		"identifier": {identifier},
+		"chamber":    {committee.ApiName},
+		"status":     {STATUS_REFERRED},
+	}, &docket); err != nil {
+		return err
+	}
+
+	current := identifier
+	if docket.Identifier != "" {
+		current = docket.Identifier
+	}
+
+	if err := removeFromAgenda(m.ChannelID, identifier); err != nil {
+		return err
+	}
+	if err := addToAgenda(committeeID, AgendaItem{
+		Identifier: current,
+		From:       m.ChannelID,
+		Added:      time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	if _, err := s.ChannelMessageSend(committeeID, "__"+current+"__ *("+item.Name+
+		")* is referred to this committee by <#"+m.ChannelID+">."); err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, identifier+" is referred to <#"+committeeID+">.")
+	return err
+}
+
+func cmdHearing(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkIsCommittee(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 3, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+
+	if ok, err := checkOnAgenda(s, m, identifier); !ok {
+		return err
+	}
+
+	at, err := time.Parse(HEARING_TIME_FORMAT, args[2]+" "+args[3])
+	if err != nil {
+		_, err = s.ChannelMessageSend(m.ChannelID, "Hearings are scheduled like 2006-01-02 15:04.")
+		return err
+	}
+
+	var witnesses []string
+	for _, arg := range args[4:] {
+		user, ok := mentionedUser(m, arg)
+		if !ok {
+			_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+		witnesses = append(witnesses, user.ID)
+	}
+
+	agenda := Agendas[m.ChannelID]
+	agenda.Hearings = append(agenda.Hearings, Hearing{
+		Identifier: identifier,
+		Time:       at,
+		Witnesses:  witnesses,
+	})
+	Agendas[m.ChannelID] = agenda
+	if err := saveAgendas(); err != nil {
+		return err
+	}
+
+	message := "A hearing on " + identifier + " is scheduled for " + at.Format(HEARING_TIME_FORMAT) + " UTC."
+	if len(witnesses) > 0 {
+		message += " Witnesses: " + mentionList(witnesses)
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, message)
+	return err
+}
+
+func cmdReport(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkIsCommittee(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 2, 3); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+	report := strings.ToLower(args[2])
+
+	if report != REPORT_FAVORABLE && report != REPORT_UNFAVORABLE {
+		_, err := s.ChannelMessageSend(m.ChannelID, "Reports can be favorable or unfavorable.")
+		return err
+	}
+
+	if ok, err := checkOnAgenda(s, m, identifier); !ok {
+		return err
+	}
+
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_REPORTED, false); !ok {
+		return err
+	}
+
+	if isActiveRollCall(m.ChannelID) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "Finish the roll call before reporting.")
+		return err
+	}
+
+	// Attach the committee's vote on the item: the archived roll call
+	// named by number, or else the latest one whose question names it.
+	vote := ""
+	if len(args) == 4 {
+		number, err := strconv.Atoi(args[3])
+		if err != nil || number < 1 {
+			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+
+		record, err := findRollCall(m.ChannelID, number)
+		if err != nil {
+			_, err = s.ChannelMessageSend(m.ChannelID, err.Error())
+			return err
+		}
+		vote = record.summary()
+	} else if record, ok := itemRollCall(m.ChannelID, identifier); ok {
+		vote = record.summary()
+	}
+
+	parentID := Chambers[m.ChannelID].Parent
+	parent, ok := Chambers[parentID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "This committee's parent is no longer a chamber.")
+		return err
+	}
+
+	var docket Docket
+	if err := apiRequest(s, m, "docket/report", url.Values{
+		"identifier":     {identifier},
+		"chamber":        {parent.ApiName},
+		"status":         {STATUS_REPORTED},
+		"recommendation": {report},
+		"vote":           {vote},
+	}, &docket); err != nil {
+		return err
+	}
+
+	current := identifier
+	if docket.Identifier != "" {
+		current = docket.Identifier
+	}
+
+	if err := removeFromAgenda(m.ChannelID, identifier); err != nil {
+		return err
+	}
+	if err := addToAgenda(parentID, AgendaItem{
+		Identifier: current,
+		From:       m.ChannelID,
+		Added:      time.Now(),
+		Report:     report,
+		Vote:       vote,
+	}); err != nil {
+		return err
+	}
+
+	notice := "__" + current + "__ is reported " + report + " by <#" + m.ChannelID + ">."
+	if vote != "" {
+		notice += "\n**Committee vote:** " + vote
+	} else {
+		notice += "\nThe committee recorded no roll call on it."
+	}
+	if _, err := s.ChannelMessageSend(parentID, notice); err != nil {
+		return err
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, identifier+" is reported to <#"+parentID+">.")
+	return err
+}
+
+func cmdAgenda(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if !isChamber(m.ChannelID) {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	agenda := Agendas[m.ChannelID]
+	if len(agenda.Items) == 0 && len(agenda.Hearings) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "Nothing is before this chamber.")
+		return err
+	}
+
+	response := "*Before this chamber:*\n"
+	for _, item := range agenda.Items {
+		response += "\n__" + item.Identifier + "__ from <#" + item.From + ">, " +
+			item.Added.UTC().Format(LEAVE_DATE_FORMAT)
+		if item.Report != "" {
+			response += ", reported " + item.Report
+		}
+		if item.Vote != "" {
+			response += " (" + item.Vote + ")"
+		}
+	}
+
+	if len(agenda.Hearings) > 0 {
+		response += "\n\n*Hearings:*\n"
+		for _, hearing := range agenda.Hearings {
+			response += "\n" + hearing.Time.Format(HEARING_TIME_FORMAT) + " UTC: " + hearing.Identifier
+			if len(hearing.Witnesses) > 0 {
+				response += ", with " + mentionList(hearing.Witnesses)
+			}
+		}
+	}
+
+	return sendSplit(s, m.ChannelID, response)
+}
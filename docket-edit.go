@@ -0,0 +1,195 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"net/url"
+	"strings"
+)
+
+const (
+	AWAIT_EDITITEM_ID = "edititem"
+
+	EDIT_NAME       = "name"
+	EDIT_SPONSOR    = "sponsor"
+	EDIT_CLASS      = "class"
+	EDIT_COSPONSORS = "cosponsors"
+)
+
+var (
+	CMD_EDITITEM = Command{
+		Handler: cmdEditItem,
+		Summary: "Correct the name, sponsor, class or cosponsors of a docketed item. " +
+			"Use `none` to clear the cosponsors.",
+		Usage: "<MOTION> <name|sponsor|class|cosponsors> <value...>",
+	}
+
+	AWAIT_EDITITEM = Await{
+		Handler: awaitEditItem,
+		ID:      AWAIT_EDITITEM_ID,
+		AddErr:  "Someone is busy editing an item on the docket. They can `" + PREFIX + "cancel` it.",
+		Timeout: CONVERSATION_TIMEOUT,
+		Expired: "Editing the item timed out.",
+		Cleanup: func(channelID string) { delete(DocketEdits, channelID) },
+		Claims:  claimsAnswer,
+	}
+)
+
+type PendingEdit struct {
+	speakerID  string
+	identifier string
+	field      string
+	values     []string
+	ids        []string // User IDs, when editing sponsors
+	clear      bool     // Whether to empty the field, e.g. remove every cosponsor
+}
+
+var DocketEdits = make(map[string]*PendingEdit)
+
+// Return the current value of the item's field as text.
+func docketField(item DocketItem, field string) string {
+	switch field {
+	case EDIT_NAME:
+		return item.Name
+	case EDIT_SPONSOR:
+		return item.Sponsor
+	case EDIT_CLASS:
+		return item.MotionClass
+	case EDIT_COSPONSORS:
+		return sponsorNames(item.Cosponsors)
+	default:
+		return ""
+	}
+}
+
+// Return a diff of a field changing from one value to another.
+func fieldDiff(field string, before string, after string) string {
+	return "```diff\n- " + field + ": " + before + "\n+ " + field + ": " + after + "\n```"
+}
+
+func cmdEditItem(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsClerk(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 3, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+	field := strings.ToLower(args[2])
+
+	var values, ids []string
+	clear := false
+	switch field {
+	case EDIT_NAME:
+		values = []string{strings.Join(args[3:], " ")}
+	case EDIT_CLASS:
+		if len(args) != 4 {
+			_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+		class, ok, err := checkSchemaValue(s, m, Schema.Classes, "motion class", args[3])
+		if !ok {
+			return err
+		}
+		values = []string{class}
+	case EDIT_SPONSOR:
+		sponsors, ok := mentionedSponsors(m, args[3:])
+		if !ok || len(sponsors) != 1 {
+			_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+		values, ids = sponsorParams(sponsors)
+	case EDIT_COSPONSORS:
+		if len(args) == 4 && args[3] == "none" {
+			clear = true
+			break
+		}
+
+		cosponsors, ok := mentionedSponsors(m, args[3:])
+		if !ok {
+			_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+		values, ids = sponsorParams(cosponsors)
+	default:
+		_, err := s.ChannelMessageSend(m.ChannelID, "Fields can be one of: name, sponsor, class, cosponsors.")
+		return err
+	}
+
+	item, err := fetchDocketItem(s, m, identifier)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := addOwnedAwait(m.ChannelID, s, AWAIT_EDITITEM, m.Author.ID); !ok {
+		return err
+	}
+
+	DocketEdits[m.ChannelID] = &PendingEdit{
+		speakerID:  m.Author.ID,
+		identifier: identifier,
+		field:      field,
+		values:     values,
+		ids:        ids,
+		clear:      clear,
+	}
+
+	after := strings.Join(values, ", ")
+	if clear {
+		after = "none"
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, "__"+identifier+"__\n"+
+		fieldDiff(field, docketField(item, field), after)+
+		"Does this look right to you? (aye/nay)")
+	return err
+}
+
+func awaitEditItem(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	edit := DocketEdits[m.ChannelID]
+	if m.Author.ID != edit.speakerID {
+		// Ignore if the speaker is not confirming the edit.
+		return nil
+	}
+
+	vote, err := parseVote(m.GuildID, m.Content)
+	if err != nil {
+		_, err := s.ChannelMessageSend(m.ChannelID, "That response doesn't make sense.")
+		return err
+	} else if vote == Abstained {
+		_, err := s.ChannelMessageSend(m.ChannelID, "An absention doesn't make sense here.")
+		return err
+	} else if vote == For {
+		if ok := removeAwait(m.ChannelID, AWAIT_EDITITEM_ID); !ok {
+			return nil
+		}
+
+		params := url.Values{
+			"identifier": {edit.identifier},
+			"field":      {edit.field},
+			"value":      edit.values,
+			"id":         edit.ids,
+		}
+		if edit.clear {
+			// Without values the API couldn't tell clearing the field
+			// from leaving it out.
+			params.Set("clear", "true")
+		}
+
+		if err := apiRequest(s, m, "docket/edit", params, nil); err != nil {
+			return err
+		}
+
+		_, err := s.ChannelMessageSend(m.ChannelID, edit.identifier+" has been updated.")
+		return err
+	} else {
+		if ok := removeAwait(m.ChannelID, AWAIT_EDITITEM_ID); !ok {
+			return nil
+		}
+
+		_, err := s.ChannelMessageSend(m.ChannelID, "OK, ignoring request to edit item.")
+		return err
+	}
+}
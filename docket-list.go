@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"strconv"
+	"strings"
+)
+
+const (
+	DOCKET_PAGE_SIZE = 10
+	DOCKET_ANY       = "*" // Matches anything in a docket filter
+	DOCKET_NAME_MAX  = 200 // Characters of an item's name shown in a listing
+)
+
+var (
+	CMD_DOCKET = Command{
+		Handler: cmdDocket,
+		Summary: "List docketed items, filtered by status, class and sponsor; use * to match anything",
+		Usage:   "[status] [class] [@sponsor] [page]",
+	}
+	CMD_DOCKETSEARCH = Command{
+		Handler: cmdDocketSearch,
+		Summary: "Search docketed items by text",
+		Usage:   "<text...>",
+	}
+)
+
+// Return an embed listing a page of docketed items.
+func docketEmbed(title string, list DocketList, next string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: title,
+	}
+
+	if len(list.Items) == 0 {
+		embed.Description = "No items found."
+		return embed
+	}
+
+	for _, item := range list.Items {
+		name := item.Name
+		if runes := []rune(name); len(runes) > DOCKET_NAME_MAX {
+			name = string(runes[:DOCKET_NAME_MAX]) + "..."
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  item.Identifier + " (" + item.MotionStatus + ")",
+			Value: name + "\n*Sponsor: " + item.Sponsor + "*",
+		})
+	}
+
+	footer := fmt.Sprintf("Page %d of %d", list.Page, list.Pages)
+	if list.Page < list.Pages && next != "" {
+		footer += " · Next page: " + next
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+
+	return embed
+}
+
+func cmdDocket(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 0, 4); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")[1:]
+	page := 1
+
+	// A trailing number is the page.
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[len(args)-1]); err == nil {
+			if n < 1 {
+				_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+				return err
+			}
+
+			page = n
+			args = args[:len(args)-1]
+		}
+	}
+
+	if len(args) > 3 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_TOO_MANY_ARGS)
+		return err
+	}
+
+	// Fill in missing filters.
+	filters := []string{DOCKET_ANY, DOCKET_ANY, DOCKET_ANY}
+	copy(filters, args)
+	status, class, sponsor := filters[0], filters[1], filters[2]
+
+	sponsorID := DOCKET_ANY
+	if sponsor != DOCKET_ANY {
+		user, ok := mentionedUser(m, sponsor)
+		if !ok {
+			_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+		sponsorID = user.ID
+	}
+
+	query := func(filter string) string {
+		if filter == DOCKET_ANY {
+			return ""
+		}
+		return filter
+	}
+
+	list, err := listDocketItems(s, m, query(status), query(class), query(sponsorID), page)
+	if err != nil {
+		return err
+	}
+
+	next := fmt.Sprintf("%sdocket %s %s %s %d", PREFIX, filters[0], filters[1], filters[2], page+1)
+	_, err = s.ChannelMessageSendEmbed(m.ChannelID, docketEmbed("Docket", list, next))
+	return err
+}
+
+func cmdDocketSearch(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 1, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	query := strings.Join(args[1:], " ")
+
+	list, err := searchDocketItems(s, m, query, 1)
+	if err != nil {
+		return err
+	}
+
+	embed := docketEmbed("Search: "+query, list, "")
+	if list.Total > len(list.Items) {
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Showing %d of %d results; try a narrower search.", len(list.Items), list.Total),
+		}
+	}
+
+	_, err = s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	return err
+}
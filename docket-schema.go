@@ -0,0 +1,167 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"log"
+	"net/url"
+	"strings"
+)
+
+const (
+	STATUS_PENDING = "pending"
+
+	OVERRIDE_FLAG = "--override"
+)
+
+// The docket schema in use, from the API or SCHEMA_PATH.
+var Schema DocketSchema
+
+// The motion classes and statuses allowed on the docket, and which
+// statuses an item may move to from each status.
+type DocketSchema struct {
+	Classes     []string            `json:"classes"`
+	Statuses    []string            `json:"statuses"`
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// Load the docket schema from the API, falling back to SCHEMA_PATH,
+// which ships with the bot.
+func loadSchema() error {
+	var schema DocketSchema
+	err := apiCall("docket/schema", url.Values{}, &schema)
+	if err == nil && len(schema.Classes) > 0 && len(schema.Statuses) > 0 {
+		Schema = schema
+		return nil
+	} else if err != nil {
+		log.Println("Couldn't fetch the docket schema:", err)
+	}
+
+	schema = DocketSchema{}
+	if err := loadSettings(&schema, SCHEMA_PATH); err != nil {
+		return err
+	}
+
+	Schema = schema
+	return nil
+}
+
+// Return whether an item may move from one status to another. Items
+// with a status the schema doesn't know may move to any status.
+func (d DocketSchema) canTransition(from string, to string) bool {
+	from, ok := matchValue(d.Statuses, from)
+	if !ok {
+		return true
+	}
+
+	for _, status := range d.Transitions[from] {
+		if strings.EqualFold(status, to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return the number of single-character edits between two strings.
+func levenshtein(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+
+			next := prev + cost
+			if row[j]+1 < next {
+				next = row[j] + 1
+			}
+			if row[j-1]+1 < next {
+				next = row[j-1] + 1
+			}
+			prev = row[j]
+			row[j] = next
+		}
+	}
+
+	return row[len(t)]
+}
+
+// Return the option closest to the value.
+func closest(options []string, value string) string {
+	best, bestDist := "", -1
+	value = strings.ToLower(value)
+	for _, option := range options {
+		if dist := levenshtein(value, strings.ToLower(option)); bestDist < 0 || dist < bestDist {
+			best, bestDist = option, dist
+		}
+	}
+
+	return best
+}
+
+// Return the option matching the value, ignoring case.
+func matchValue(options []string, value string) (string, bool) {
+	for _, option := range options {
+		if strings.EqualFold(option, value) {
+			return option, true
+		}
+	}
+
+	return "", false
+}
+
+// Return the value as spelled in the options, or send a message
+// suggesting the closest option if it isn't one. kind names the kind
+// of value, e.g. "motion class".
+func checkSchemaValue(s *discordgo.Session, m *discordgo.MessageCreate,
+	options []string, kind string, value string) (string, bool, error) {
+
+	if match, ok := matchValue(options, value); ok {
+		return match, true, nil
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, "'"+value+"' isn't a "+kind+
+		". Did you mean '"+closest(options, value)+"'? It can be one of: "+
+		strings.Join(options, ", ")+".")
+	return "", false, err
+}
+
+// Split a trailing override flag off the arguments.
+func splitOverride(args []string) ([]string, bool) {
+	if len(args) > 0 && args[len(args)-1] == OVERRIDE_FLAG {
+		return args[:len(args)-1], true
+	}
+
+	return args, false
+}
+
+// Return whether the docketed item may take the status, and send a
+// message if it can't. Channel managers may override the transitions.
+func checkStatusChange(s *discordgo.Session, m *discordgo.MessageCreate,
+	identifier string, status string, override bool) (bool, error) {
+
+	item, err := fetchDocketItem(s, m, identifier)
+	if err != nil {
+		return false, err
+	}
+
+	if Schema.canTransition(item.MotionStatus, status) {
+		return true, nil
+	}
+
+	if override {
+		return checkAuthorCanManageChannels(s, m)
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, identifier+" can't go from "+item.MotionStatus+
+		" to "+status+". Channel managers can add "+OVERRIDE_FLAG+" to force it.")
+	return false, err
+}
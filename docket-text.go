@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"github.com/bwmarrin/discordgo"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The largest bill text accepted, in bytes.
+	BILL_TEXT_MAX = 1 << 20
+)
+
+var (
+	ERR_BAD_ATTACHMENT = errors.New("Only .txt and .md files can be used as bill text.")
+	ERR_TEXT_TOO_LONG  = errors.New("That bill text is too long.")
+	ERR_DOWNLOAD       = errors.New("Couldn't download that attachment.")
+)
+
+var (
+	CMD_DONE = Command{
+		Handler: cmdDone,
+		Summary: "Finish giving the text of an item being added to the docket",
+	}
+)
+
+// Download the text of a .txt or .md attachment.
+func attachmentText(attachment *discordgo.MessageAttachment) (string, error) {
+	ext := strings.ToLower(path.Ext(attachment.Filename))
+	if ext != ".txt" && ext != ".md" {
+		return "", ERR_BAD_ATTACHMENT
+	}
+
+	if attachment.Size > BILL_TEXT_MAX {
+		return "", ERR_TEXT_TOO_LONG
+	}
+
+	res, err := http.Get(attachment.URL)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	// Don't take an error page for the bill text.
+	if res.StatusCode != http.StatusOK {
+		return "", ERR_DOWNLOAD
+	}
+
+	// Read one byte past the limit to tell a full file from a long one.
+	text, err := ioutil.ReadAll(io.LimitReader(res.Body, BILL_TEXT_MAX+1))
+	if err != nil {
+		return "", err
+	} else if len(text) > BILL_TEXT_MAX {
+		return "", ERR_TEXT_TOO_LONG
+	}
+
+	return string(text), nil
+}
+
+// Add a message, and any attached text files, to the text of the item
+// being added to the docket.
+func addBillText(s *discordgo.Session, m *discordgo.MessageCreate, docketItem *PendingDocketItem) error {
+	parts := []string{}
+	if m.Content != "" {
+		parts = append(parts, m.Content)
+	}
+
+	for _, attachment := range m.Attachments {
+		text, err := attachmentText(attachment)
+		if err == ERR_BAD_ATTACHMENT || err == ERR_TEXT_TOO_LONG || err == ERR_DOWNLOAD {
+			_, err = s.ChannelMessageSend(m.ChannelID, err.Error())
+			return err
+		} else if err != nil {
+			return err
+		}
+		parts = append(parts, text)
+	}
+
+	text := docketItem.text
+	for _, part := range parts {
+		if text != "" {
+			text += "\n"
+		}
+		text += part
+	}
+
+	if len(text) > BILL_TEXT_MAX {
+		_, err := s.ChannelMessageSend(m.ChannelID, ERR_TEXT_TOO_LONG.Error())
+		return err
+	}
+
+	docketItem.text = text
+	return nil
+}
+
+// Upload the text of a docketed item as a file with a message.
+func sendBillText(s *discordgo.Session, channelID string, message string, name string, text string) error {
+	_, err := s.ChannelFileSendWithMessage(channelID, message, name+".md", strings.NewReader(text))
+	return err
+}
+
+func cmdDone(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	docketItem, ok := DocketItems[m.ChannelID]
+	await, waiting := findAwait(m.ChannelID, AWAIT_ADD_DOCKET_ITEM_ID)
+	if !ok || !waiting ||
+		docketItem.pendingStatus != PENDINGITEM_TEXT || m.Author.ID != docketItem.speakerID {
+
+		_, err := s.ChannelMessageSend(m.ChannelID, "You aren't giving the text of an item.")
+		return err
+	}
+
+	if docketItem.text == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "The item doesn't have any text yet.")
+		return err
+	}
+
+	docketItem.pendingStatus = PENDINGITEM_CONF
+	await.touch()
+
+	return sendBillText(s, m.ChannelID, "**Title:** "+docketItem.name+"\n**Text:** "+
+		strconv.Itoa(len([]rune(docketItem.text)))+" characters, attached\n\n"+
+		"Does this look right to you? (aye/nay)", "text", docketItem.text)
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	STATUS_PRESENTED    = "presented"
+	STATUS_SIGNED       = "signed"
+	STATUS_VETOED       = "vetoed"
+	STATUS_OVERRIDDEN   = "overridden"
+	STATUS_ENACTED      = "enacted"
+	STATUS_POCKETVETOED = "pocketvetoed"
+
+	DEFAULT_VETO_DAYS    = 10
+	DEFAULT_OVERRIDE_NUM = 2
+	DEFAULT_OVERRIDE_DEN = 3
+	DEFAULT_OVERRIDE     = 60 // Minutes an override roll call stays open
+)
+
+var (
+	CMD_SETEXECUTIVE = Command{
+		Handler: cmdSetExecutive,
+		Summary: "Make this channel the executive's, where passed items are presented, " +
+			"and set the role that may sign and veto them",
+		Usage: "[@role]",
+	}
+	CMD_DESK = Command{
+		Handler: cmdDesk,
+		Summary: "List the items awaiting the executive's signature, and their deadlines",
+	}
+	CMD_SIGN = Command{
+		Handler: cmdSign,
+		Summary: "Sign an item presented to the executive into law",
+		Usage:   "<MOTION>",
+	}
+	CMD_VETO = Command{
+		Handler: cmdVeto,
+		Summary: "Veto an item presented to the executive",
+		Usage:   "<MOTION> [reason...]",
+	}
+	CMD_OVERRIDE = Command{
+		Handler: cmdOverride,
+		Summary: "Start a roll call in each chamber to override the veto of an item",
+		Usage:   "<MOTION> [minutes]",
+	}
+)
+
+// Provided by executive.json
+type ExecutiveSettings struct {
+	Channel     string   `json:"channel"`  // Channel where items are presented to the executive
+	Role        string   `json:"role"`     // Role that may sign and veto items
+	VetoDays    int      `json:"vetodays"` // Days the executive has to act on an item
+	OverrideNum int      `json:"overridenum"`
+	OverrideDen int      `json:"overrideden"`
+	Chambers    []string `json:"chambers"`   // Chambers that vote on overrides; all of them if empty
+	PocketVeto  bool     `json:"pocketveto"` // Whether items left unsigned are vetoed rather than enacted
+}
+
+// An item awaiting the executive's signature.
+type DeskItem struct {
+	From      string    `json:"from"` // Chamber that passed the item last
+	Presented time.Time `json:"presented"`
+	Deadline  time.Time `json:"deadline"`
+}
+
+// A veto override being voted on in each chamber.
+type Override struct {
+	identifier string
+	results    map[string]bool // Map from chamber channel ID to whether it voted to override
+	chambers   int
+}
+
+var Desk = make(map[string]DeskItem)
+var Overrides = make(map[string]*Override)
+
+func saveExecutive() error {
+	file, err := os.Create(EXECUTIVE_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(Executive)
+
+	return file.Close()
+}
+
+func saveDesk() error {
+	file, err := os.Create(DESK_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(Desk)
+
+	return file.Close()
+}
+
+// Return the days the executive has to act on an item.
+func vetoDays() int {
+	if Executive.VetoDays > 0 {
+		return Executive.VetoDays
+	}
+
+	return DEFAULT_VETO_DAYS
+}
+
+// Return the votes required to override a veto.
+func overrideThreshold() (int, int) {
+	if Executive.OverrideNum > 0 && Executive.OverrideDen > 0 {
+		return Executive.OverrideNum, Executive.OverrideDen
+	}
+
+	return DEFAULT_OVERRIDE_NUM, DEFAULT_OVERRIDE_DEN
+}
+
+// Return the channel IDs of the chambers that vote on overrides: by
+// default every chamber that isn't a committee.
+func overrideChambers() []string {
+	if len(Executive.Chambers) > 0 {
+		return Executive.Chambers
+	}
+
+	var chambers []string
+	for channelID, chamber := range Chambers {
+		if chamber.Parent == "" {
+			chambers = append(chambers, channelID)
+		}
+	}
+	return chambers
+}
+
+// Put an item on the executive's desk, to be enacted or pocket vetoed
+// if the executive doesn't act before the deadline.
+func presentToExecutive(s *discordgo.Session, identifier string, from string) error {
+	now := time.Now()
+	item := DeskItem{
+		From:      from,
+		Presented: now,
+		Deadline:  now.AddDate(0, 0, vetoDays()),
+	}
+
+	Desk[identifier] = item
+	if err := saveDesk(); err != nil {
+		return err
+	}
+
+	scheduleDeadline(s, identifier, item)
+	return nil
+}
+
+// Take an item off the executive's desk.
+func removeFromDesk(identifier string) error {
+	delete(Desk, identifier)
+	return saveDesk()
+}
+
+// Act on the item once its deadline passes, unless the executive has
+// acted on it first.
+func scheduleDeadline(s *discordgo.Session, identifier string, item DeskItem) {
+	go func() {
+		time.Sleep(time.Until(item.Deadline))
+
+		CommandMutex.Lock()
+		defer CommandMutex.Unlock()
+
+		if current, ok := Desk[identifier]; !ok || !current.Deadline.Equal(item.Deadline) {
+			return
+		}
+
+		status, outcome := STATUS_ENACTED, " becomes law without the executive's signature."
+		if Executive.PocketVeto {
+			status, outcome = STATUS_POCKETVETOED, " is pocket vetoed."
+		}
+
+		if err := apiCall("docket/status", url.Values{
+			"identifier": {identifier},
+			"status":     {status},
+		}, nil); err != nil {
+			log.Println("Error acting on", identifier, "at its deadline:", err)
+			return
+		}
+
+		if err := removeFromDesk(identifier); err != nil {
+			log.Println("Error saving the executive's desk:", err)
+		}
+
+		channelID := Executive.Channel
+		if channelID == "" {
+			channelID = item.From
+		}
+		if _, err := s.ChannelMessageSend(channelID, "The deadline has passed; "+identifier+outcome); err != nil {
+			log.Println("Error announcing", identifier, "at its deadline:", err)
+		}
+	}()
+}
+
+// Schedule the deadlines of every item on the executive's desk.
+func scheduleDesk(s *discordgo.Session) {
+	for identifier, item := range Desk {
+		scheduleDeadline(s, identifier, item)
+	}
+}
+
+// Return whether the item is on the executive's desk, and send a
+// message if it isn't.
+func checkOnDesk(s *discordgo.Session, m *discordgo.MessageCreate, identifier string) (bool, error) {
+	if _, ok := Desk[identifier]; ok {
+		return true, nil
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, identifier+" isn't awaiting the executive's signature.")
+	return false, err
+}
+
+// Record one chamber's override vote, and settle the override once
+// every chamber has voted.
+func (o *Override) result(s *discordgo.Session, channelID string, passed bool) error {
+	if Overrides[o.identifier] != o {
+		// The override was already settled.
+		return nil
+	}
+
+	o.results[channelID] = passed
+	if len(o.results) < o.chambers {
+		return nil
+	}
+	delete(Overrides, o.identifier)
+
+	overridden := true
+	for _, passed := range o.results {
+		overridden = overridden && passed
+	}
+
+	outcome := " is sustained."
+	if overridden {
+		outcome = " is overridden, and it becomes law."
+		if err := apiCall("docket/status", url.Values{
+			"identifier": {o.identifier},
+			"status":     {STATUS_OVERRIDDEN},
+		}, nil); err != nil {
+			return err
+		}
+	}
+
+	// Announce the outcome in every chamber that voted, and to the
+	// executive.
+	channels := make([]string, 0, len(o.results)+1)
+	for chamberID := range o.results {
+		channels = append(channels, chamberID)
+	}
+	if _, voted := o.results[Executive.Channel]; Executive.Channel != "" && !voted {
+		channels = append(channels, Executive.Channel)
+	}
+
+	for _, announceID := range channels {
+		if _, err := s.ChannelMessageSend(announceID, "The veto of "+o.identifier+outcome); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cmdSetExecutive(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 0, 1); !ok {
+		return err
+	}
+
+	if len(m.MentionRoles) > 1 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	Executive.Channel = m.ChannelID
+	if len(m.MentionRoles) == 1 {
+		Executive.Role = m.MentionRoles[0]
+	}
+
+	if err := saveExecutive(); err != nil {
+		return err
+	}
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
+
+func cmdDesk(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if len(Desk) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "Nothing is awaiting the executive's signature.")
+		return err
+	}
+
+	response := "*Awaiting the executive's signature:*\n"
+	for identifier, item := range Desk {
+		response += "\n__" + identifier + "__ from <#" + item.From + ">, due " +
+			item.Deadline.UTC().Format(LEAVE_DATE_FORMAT)
+	}
+
+	return sendSplit(s, m.ChannelID, response)
+}
+
+func cmdSign(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsExecutive(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+
+	if ok, err := checkOnDesk(s, m, identifier); !ok {
+		return err
+	}
+
+	if err := setItemStatus(s, m, identifier, STATUS_SIGNED); err != nil {
+		return err
+	}
+
+	if err := removeFromDesk(identifier); err != nil {
+		return err
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, identifier+" is signed into law.")
+	return err
+}
+
+func cmdVeto(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsExecutive(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+	reason := strings.Join(args[2:], " ")
+
+	if ok, err := checkOnDesk(s, m, identifier); !ok {
+		return err
+	}
+	from := Desk[identifier].From
+
+	if err := setItemStatus(s, m, identifier, STATUS_VETOED); err != nil {
+		return err
+	}
+
+	if reason != "" {
+		if err := apiRequest(s, m, "docket/comment", url.Values{
+			"identifier": {identifier},
+			"comment":    {"Vetoed: " + reason},
+		}, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := removeFromDesk(identifier); err != nil {
+		return err
+	}
+
+	message := identifier + " is vetoed."
+	if reason != "" {
+		message += " Reason: " + reason
+	}
+	if _, err := s.ChannelMessageSend(m.ChannelID, message); err != nil {
+		return err
+	}
+
+	// Let the chamber the item came from know it can try to override.
+	if from != m.ChannelID {
+		_, err := s.ChannelMessageSend(from, message+" The veto may be overridden with `"+
+			PREFIX+"override "+identifier+"`.")
+		return err
+	}
+	return nil
+}
+
+// End an override roll call in the chamber without acting on, or
+// archiving, its outcome.
+func withdrawOverrideVote(s *discordgo.Session, channelID string) error {
+	rollCall, ok := RollCalls[channelID]
+	if !ok || !removeAwait(channelID, AWAIT_CALL_ID) {
+		return nil
+	}
+
+	rollCall.active = false
+	rollCall.timerActive = false
+	rollCall.onResult = nil
+	updateRollCallStatus(s, channelID, rollCall)
+	// Nothing is left to resume.
+	delete(RollCalls, channelID)
+
+	_, err := s.ChannelMessageSend(channelID, "The override vote is withdrawn because it couldn't "+
+		"start in every chamber.")
+	return err
+}
+
+func cmdOverride(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 2); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+
+	duration := DEFAULT_OVERRIDE
+	if len(args) == 3 {
+		minutes, err := strconv.Atoi(args[2])
+		if err != nil || minutes <= 0 {
+			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+		duration = minutes
+	}
+
+	if _, ok := Overrides[identifier]; ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "The chambers are already voting to override "+
+			identifier+".")
+		return err
+	}
+
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_OVERRIDDEN, false); !ok {
+		return err
+	}
+
+	chambers := overrideChambers()
+	for _, channelID := range chambers {
+		if isActiveRollCall(channelID) {
+			_, err := s.ChannelMessageSend(m.ChannelID, "<#"+channelID+"> already has a roll call open.")
+			return err
+		}
+	}
+
+	override := &Override{
+		identifier: identifier,
+		results:    make(map[string]bool),
+		chambers:   len(chambers),
+	}
+	Overrides[identifier] = override
+
+	num, den := overrideThreshold()
+	var started []string
+	for _, channelID := range chambers {
+		channelID := channelID
+
+		rollCall, err := startRollCall(s, channelID, duration, num, den,
+			"overriding the veto of "+identifier)
+		if rollCall != nil {
+			started = append(started, channelID)
+			rollCall.onResult = func(passed bool) error {
+				return override.result(s, channelID, passed)
+			}
+		}
+
+		if rollCall == nil || err != nil {
+			// Without every chamber voting, the override can't succeed,
+			// so the votes already under way are moot.
+			delete(Overrides, identifier)
+			for _, startedID := range started {
+				if werr := withdrawOverrideVote(s, startedID); werr != nil && err == nil {
+					err = werr
+				}
+			}
+
+			if rollCall == nil && err == nil {
+				_, err = s.ChannelMessageSend(m.ChannelID, "The override vote couldn't start in <#"+
+					channelID+">.")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
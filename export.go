@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EXPORT_CSV  = "csv"
+	EXPORT_JSON = "json"
+	EXPORT_MD   = "md"
+
+	EXPORT_CALL   = "call"
+	EXPORT_DOCKET = "docket"
+
+	NOT_VOTING = "Not voting"
+	EXCUSED    = "Excused"
+)
+
+var (
+	ERR_BAD_FORMAT   = errors.New("Formats can be one of: csv, json, md.")
+	ERR_NO_ROLL_CALL = errors.New("There is no such roll call.")
+	ERR_EXPORT_USAGE = errors.New("usage: committee-clerk export <csv|json|md> call <channel ID> [number]\n" +
+		"       committee-clerk export <csv|json|md> docket <identifier> ...")
+)
+
+var (
+	CMD_EXPORT = Command{
+		Handler: cmdExport,
+		Summary: "Upload the current or an archived roll call, or docketed items, as a file",
+		Usage:   "<csv|json|md> [call [number] | docket <MOTION> ...]",
+	}
+)
+
+// A roll call as exported, with votes spelled out.
+type RollCallExport struct {
+	Motion   string            `json:"motion"`
+	Start    time.Time         `json:"start"`
+	End      time.Time         `json:"end"`
+	Required string            `json:"required"`
+	Passed   *bool             `json:"passed"` // Null while the vote is in progress
+	Votes    map[string]string `json:"votes"`  // Map from UserID to vote
+	Names    map[string]string `json:"names"`  // Map from UserID to display name
+}
+
+// Return whether the string is an export format.
+func isExportFormat(format string) bool {
+	return format == EXPORT_CSV || format == EXPORT_JSON || format == EXPORT_MD
+}
+
+// Escape text for a Markdown table cell.
+func mdCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+// Return the roll call in the channel to export. number picks an
+// archived roll call counting from one; zero picks the current or
+// most recent one.
+func findRollCall(channelID string, number int) (RollCallRecord, error) {
+	records := Archive[channelID]
+
+	if number == 0 {
+		if rollCall, ok := RollCalls[channelID]; ok && rollCall.active {
+			record := rollCall.record(false)
+			record.inProgress = true
+			return record, nil
+		}
+		number = len(records)
+	}
+
+	if number < 1 || number > len(records) {
+		return RollCallRecord{}, ERR_NO_ROLL_CALL
+	}
+
+	return records[number-1], nil
+}
+
+// Spell out the votes in the roll call, looking up each member's name.
+func exportableRollCall(s *discordgo.Session, record RollCallRecord) (RollCallExport, error) {
+	export := RollCallExport{
+		Motion:   record.Motion,
+		Start:    record.Start,
+		End:      record.End,
+		Required: strconv.Itoa(record.PassNum) + "/" + strconv.Itoa(record.PassDen),
+		Votes:    make(map[string]string),
+		Names:    make(map[string]string),
+	}
+	if !record.inProgress {
+		passed := record.Passed
+		export.Passed = &passed
+	}
+
+	for _, userID := range record.Members {
+		export.Votes[userID] = NOT_VOTING
+		if vote, ok := record.Votes[userID]; ok {
+			export.Votes[userID] = vote.String()
+		}
+	}
+	for _, userID := range record.Excused {
+		export.Votes[userID] = EXCUSED
+	}
+
+	for userID := range export.Votes {
+		user, err := s.User(userID)
+		if err != nil {
+			return export, err
+		}
+		export.Names[userID] = displayName(user)
+	}
+
+	return export, nil
+}
+
+// Write the roll call to w in the given format.
+func exportRollCall(w io.Writer, format string, export RollCallExport) error {
+	userIDs := make([]string, 0, len(export.Votes))
+	for userID := range export.Votes {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	switch format {
+	case EXPORT_CSV:
+		cw := csv.NewWriter(w)
+		// The passed column is empty while the vote is in progress.
+		passed := ""
+		if export.Passed != nil {
+			passed = strconv.FormatBool(*export.Passed)
+		}
+
+		cw.Write([]string{"motion", "end", "required", "passed", "user_id", "name", "vote"})
+		for _, userID := range userIDs {
+			cw.Write([]string{export.Motion, export.End.Format(time.RFC3339), export.Required,
+				passed, userID, export.Names[userID], export.Votes[userID]})
+		}
+		cw.Flush()
+		return cw.Error()
+	case EXPORT_JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(export)
+	case EXPORT_MD:
+		ended, result := "Ended", "Not agreed to"
+		if export.Passed == nil {
+			ended, result = "As of", "In progress"
+		} else if *export.Passed {
+			result = "Agreed to"
+		}
+
+		fmt.Fprintf(w, "# Roll call: %s\n\n", mdCell(export.Motion))
+		fmt.Fprintf(w, "- **%s:** %s\n- **Required:** %s\n- **Result:** %s\n\n",
+			ended, export.End.Format(time.RFC1123), export.Required, result)
+		fmt.Fprintln(w, "| Member | Vote |")
+		fmt.Fprintln(w, "| --- | --- |")
+		for _, userID := range userIDs {
+			fmt.Fprintf(w, "| %s | %s |\n", mdCell(export.Names[userID]), export.Votes[userID])
+		}
+		return nil
+	default:
+		return ERR_BAD_FORMAT
+	}
+}
+
+// Write the docketed items to w in the given format.
+func exportDocket(w io.Writer, format string, items []DocketItem) error {
+	switch format {
+	case EXPORT_CSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"identifier", "status", "class", "number", "name", "sponsor", "cosponsors", "comment", "date"})
+		for _, item := range items {
+			cw.Write([]string{item.Identifier, item.MotionStatus, item.MotionClass,
+				strconv.Itoa(item.ClassNumber), item.Name, item.Sponsor, sponsorNames(item.Cosponsors), item.Comment, item.Date})
+		}
+		cw.Flush()
+		return cw.Error()
+	case EXPORT_JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	case EXPORT_MD:
+		fmt.Fprintln(w, "| Identifier | Status | Class | Name | Sponsor | Cosponsors | Comment | Date |")
+		fmt.Fprintln(w, "| --- | --- | --- | --- | --- | --- | --- | --- |")
+		for _, item := range items {
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s | %s |\n", mdCell(item.Identifier),
+				mdCell(item.MotionStatus), mdCell(item.MotionClass), mdCell(item.Name),
+				mdCell(item.Sponsor), mdCell(sponsorNames(item.Cosponsors)), mdCell(item.Comment),
+				mdCell(item.Date))
+		}
+		return nil
+	default:
+		return ERR_BAD_FORMAT
+	}
+}
+
+// Run the export subcommand, writing the export named by args to w.
+func runExport(s *discordgo.Session, args []string, w io.Writer) error {
+	if len(args) < 3 || !isExportFormat(args[0]) {
+		return ERR_EXPORT_USAGE
+	}
+	format := args[0]
+
+	switch args[1] {
+	case EXPORT_CALL:
+		if len(args) > 4 {
+			return ERR_EXPORT_USAGE
+		}
+
+		number := 0
+		if len(args) == 4 {
+			var err error
+			if number, err = strconv.Atoi(args[3]); err != nil {
+				return ERR_EXPORT_USAGE
+			}
+		}
+
+		record, err := findRollCall(args[2], number)
+		if err != nil {
+			return err
+		}
+
+		export, err := exportableRollCall(s, record)
+		if err != nil {
+			return err
+		}
+		return exportRollCall(w, format, export)
+	case EXPORT_DOCKET:
+		var items []DocketItem
+		for _, identifier := range args[2:] {
+			var item DocketItem
+			if err := apiCall("docket/read", url.Values{
+				"identifier": {identifier},
+			}, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		return exportDocket(w, format, items)
+	default:
+		return ERR_EXPORT_USAGE
+	}
+}
+
+func cmdExport(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 1, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	format := args[1]
+	if !isExportFormat(format) {
+		_, err := s.ChannelMessageSend(m.ChannelID, ERR_BAD_FORMAT.Error())
+		return err
+	}
+
+	kind := EXPORT_CALL
+	if len(args) > 2 {
+		kind = args[2]
+	}
+
+	var buf bytes.Buffer
+	var filename string
+
+	switch kind {
+	case EXPORT_CALL:
+		if ok, err := checkArgRange(s, m, 1, 3); !ok {
+			return err
+		}
+
+		number := 0
+		if len(args) == 4 {
+			var err error
+			if number, err = strconv.Atoi(args[3]); err != nil {
+				_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+				return err
+			}
+		}
+
+		record, err := findRollCall(m.ChannelID, number)
+		if err == ERR_NO_ROLL_CALL {
+			_, err = s.ChannelMessageSend(m.ChannelID, err.Error())
+			return err
+		}
+
+		export, err := exportableRollCall(s, record)
+		if err != nil {
+			return err
+		}
+
+		if err := exportRollCall(&buf, format, export); err != nil {
+			return err
+		}
+		filename = "rollcall." + format
+	case EXPORT_DOCKET:
+		if ok, err := checkArgRange(s, m, 3, ARGS_NO_LIMIT); !ok {
+			return err
+		}
+
+		var items []DocketItem
+		for _, identifier := range args[3:] {
+			item, err := fetchDocketItem(s, m, identifier)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+
+		if err := exportDocket(&buf, format, items); err != nil {
+			return err
+		}
+		filename = "docket." + format
+	default:
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	_, err := s.ChannelFileSend(m.ChannelID, filename, &buf)
+	return err
+}
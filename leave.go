@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	LEAVE_DATE_FORMAT = "2006-01-02"
+)
+
+var (
+	CMD_LEAVE = Command{
+		Handler: cmdLeave,
+		Summary: "Excuse a member from roll calls between two dates (YYYY-MM-DD), inclusive",
+		Usage:   "<member> <from> <to> [reason...]",
+	}
+	CMD_ENDLEAVE = Command{
+		Handler: cmdEndLeave,
+		Summary: "End a member's leave of absence early",
+		Usage:   "<member>",
+	}
+	CMD_LEAVES = Command{
+		Handler: cmdLeaves,
+		Summary: "List current and upcoming leaves of absence in the chamber",
+	}
+)
+
+type Leave struct {
+	UserID string    `json:"user"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"` // Last day of the leave
+	Reason string    `json:"reason"`
+}
+
+// Return whether the leave has passed its end date.
+func (l Leave) ended(t time.Time) bool {
+	return !t.Before(l.To.AddDate(0, 0, 1))
+}
+
+func saveLeaves() error {
+	file, err := os.Create(LEAVE_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(Leaves)
+
+	return file.Close()
+}
+
+// Return whether the member is on leave from the chamber at the time.
+func onLeave(channelID string, userID string, t time.Time) bool {
+	for _, leave := range Leaves[channelID] {
+		if leave.UserID == userID && !t.Before(leave.From) && !leave.ended(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Remove the chamber's leaves that have ended.
+func pruneLeaves(channelID string) error {
+	leaves := Leaves[channelID]
+	now := time.Now()
+	pruned := false
+
+	for i := 0; i < len(leaves); i++ {
+		if leaves[i].ended(now) {
+			leaves = append(leaves[:i], leaves[i+1:]...)
+			pruned = true
+			i--
+		}
+	}
+
+	if !pruned {
+		return nil
+	}
+
+	Leaves[channelID] = leaves
+	return saveLeaves()
+}
+
+func cmdLeave(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 3, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	if len(m.Mentions) != 1 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	from, err := time.Parse(LEAVE_DATE_FORMAT, args[2])
+	if err != nil {
+		_, err = s.ChannelMessageSend(m.ChannelID, "Dates must look like "+LEAVE_DATE_FORMAT+".")
+		return err
+	}
+
+	to, err := time.Parse(LEAVE_DATE_FORMAT, args[3])
+	if err != nil {
+		_, err = s.ChannelMessageSend(m.ChannelID, "Dates must look like "+LEAVE_DATE_FORMAT+".")
+		return err
+	}
+
+	if to.Before(from) {
+		_, err = s.ChannelMessageSend(m.ChannelID, "The leave can't end before it starts.")
+		return err
+	}
+
+	member := m.Mentions[0]
+	Leaves[m.ChannelID] = append(Leaves[m.ChannelID], Leave{
+		UserID: member.ID,
+		From:   from,
+		To:     to,
+		Reason: strings.Join(args[4:], " "),
+	})
+
+	if err := saveLeaves(); err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, address(m.ChannelID, member)+" is excused from "+
+		args[2]+" to "+args[3]+".")
+	return err
+}
+
+func cmdEndLeave(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	if len(m.Mentions) != 1 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	member := m.Mentions[0]
+	leaves := Leaves[m.ChannelID]
+	ended := false
+
+	for i := 0; i < len(leaves); i++ {
+		if leaves[i].UserID == member.ID {
+			leaves = append(leaves[:i], leaves[i+1:]...)
+			ended = true
+			i--
+		}
+	}
+
+	if !ended {
+		_, err := s.ChannelMessageSend(m.ChannelID, address(m.ChannelID, member)+" isn't on leave.")
+		return err
+	}
+
+	Leaves[m.ChannelID] = leaves
+	if err := saveLeaves(); err != nil {
+		return err
+	}
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
+
+func cmdLeaves(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if !isChamber(m.ChannelID) {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	if err := pruneLeaves(m.ChannelID); err != nil {
+		return err
+	}
+
+	if len(Leaves[m.ChannelID]) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "No members are on leave.")
+		return err
+	}
+
+	response := "*Leaves of absence:*\n"
+	for _, leave := range Leaves[m.ChannelID] {
+		user, err := s.User(leave.UserID)
+		if err != nil {
+			return err
+		}
+
+		response += "\n" + address(m.ChannelID, user) + ": " + leave.From.Format(LEAVE_DATE_FORMAT) +
+			" to " + leave.To.Format(LEAVE_DATE_FORMAT)
+		if leave.Reason != "" {
+			response += " (" + leave.Reason + ")"
+		}
+	}
+
+	return sendSplit(s, m.ChannelID, response)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"log"
+)
+
+func messageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Updates without an author, such as embeds loading, don't change
+	// the content.
+	if m.Author == nil || m.Author.Bot {
+		return
+	}
+
+	CommandMutex.Lock()
+	defer CommandMutex.Unlock()
+
+	if err := revoteEdited(s, m.Message); err != nil {
+		log.Println("Error handling edited vote:", err)
+	}
+
+	if !isObjection(m.Content) {
+		if err := withdrawObjection(s, m.ChannelID, m.ID); err != nil {
+			log.Println("Error handling edited objection:", err)
+		}
+	}
+}
+
+func messageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
+	CommandMutex.Lock()
+	defer CommandMutex.Unlock()
+
+	if rollCall, userID, ok := voteMessage(m.ChannelID, m.ID); ok {
+		if err := retractMessageVote(s, rollCall, userID, ""); err != nil {
+			log.Println("Error handling deleted vote:", err)
+		}
+	}
+
+	if err := withdrawObjection(s, m.ChannelID, m.ID); err != nil {
+		log.Println("Error handling deleted objection:", err)
+	}
+}
+
+// Return the active roll call in which the message gave a member's
+// current vote, and the member.
+func voteMessage(channelID string, messageID string) (*RollCall, string, bool) {
+	rollCall, ok := RollCalls[channelID]
+	if !ok || !rollCall.active {
+		return nil, "", false
+	}
+
+	for userID, voteMessageID := range rollCall.voteMessages {
+		if voteMessageID == messageID {
+			return rollCall, userID, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// Withdraw the member's vote after the message or reaction that gave
+// it was edited or removed. reactedTo is the message the bot reacted to
+// with the vote, if any.
+func retractMessageVote(s *discordgo.Session, rollCall *RollCall, userID string, reactedTo string) error {
+	previous := rollCall.votes[userID]
+
+	if ok, reason := rollCall.retractVote(userID); !ok {
+		_, err := s.ChannelMessageSend(rollCall.channelID, "<@"+userID+"> "+reason+
+			" Your vote of '"+previous.String()+"' stands.")
+		return err
+	}
+	delete(rollCall.voteMessages, userID)
+	updateRollCallStatus(s, rollCall.channelID, rollCall)
+
+	if reactedTo != "" {
+		return s.MessageReactionRemove(rollCall.channelID, reactedTo,
+			vocabulary(rollCall.guildID).words(previous).React, "@me")
+	}
+	return nil
+}
+
+// Re-read the vote in an edited message that gave a member's vote.
+func revoteEdited(s *discordgo.Session, m *discordgo.Message) error {
+	rollCall, userID, ok := voteMessage(m.ChannelID, m.ID)
+	if !ok {
+		return nil
+	}
+
+	vote, err := parseVote(rollCall.guildID, m.Content)
+	if err != nil {
+		// The message is no longer a vote.
+		return retractMessageVote(s, rollCall, userID, m.ID)
+	}
+
+	previous := rollCall.votes[userID]
+	if vote == previous {
+		return nil
+	}
+
+	if ok, reason := rollCall.recordVote(userID, vote, ""); !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "<@"+userID+"> "+reason+
+			" Your vote of '"+previous.String()+"' stands.")
+		return err
+	}
+	updateRollCallStatus(s, m.ChannelID, rollCall)
+
+	vocab := vocabulary(rollCall.guildID)
+	if err := s.MessageReactionRemove(m.ChannelID, m.ID, vocab.words(previous).React, "@me"); err != nil {
+		return err
+	}
+	if err := s.MessageReactionAdd(m.ChannelID, m.ID, vocab.words(vote).React); err != nil {
+		return err
+	}
+
+	return stopOnQuorum(s, rollCall)
+}
+
+// Withdraw an objection to the channel's unanimous consent request
+// once the message making it is edited or deleted, unless the member
+// objected in another message too.
+func withdrawObjection(s *discordgo.Session, channelID string, messageID string) error {
+	request, ok := UnanimousRequests[channelID]
+	if !ok || !request.active {
+		return nil
+	}
+
+	userID, ok := request.objections[messageID]
+	if !ok {
+		return nil
+	}
+	delete(request.objections, messageID)
+
+	for _, objector := range request.objections {
+		if objector == userID {
+			return nil
+		}
+	}
+
+	if !request.withdraw(userID) {
+		return nil
+	}
+
+	_, err := s.ChannelMessageSend(channelID, "Objection from <@"+userID+"> is withdrawn.")
+	return err
+}
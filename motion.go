@@ -0,0 +1,362 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MOTION_SECONDING = iota
+	MOTION_DEBATE
+)
+
+const (
+	DEFAULT_SECOND_TIMEOUT = 5  // Minutes a motion waits for a second
+	DEFAULT_DEBATE         = 30 // Minutes of debate when a chamber sets none
+	DEFAULT_SPEAKING       = 5  // Minutes per member when a chamber sets none
+
+	MSG_NO_MOTION = "There is no pending motion."
+	MSG_NO_DEBATE = "The motion is not being debated."
+)
+
+var (
+	CMD_MOVE = Command{
+		Handler: cmdMove,
+		Summary: "Move a motion, which must be seconded before it is debated",
+		Usage:   "<motion...>",
+	}
+	CMD_SECOND = Command{
+		Handler: cmdSecond,
+		Summary: "Second the pending motion and open debate",
+	}
+	CMD_FLOOR = Command{
+		Handler: cmdFloor,
+		Summary: "Take the floor to speak in debate",
+	}
+	CMD_YIELD = Command{
+		Handler: cmdYield,
+		Summary: "Yield the floor back to the chamber",
+	}
+	CMD_PREVIOUSQUESTION = Command{
+		Handler: cmdPreviousQuestion,
+		Summary: "End debate and put the motion to a roll-call vote",
+		Usage:   "[minutes]",
+	}
+	CMD_SETDEBATE = Command{
+		Handler: cmdSetDebate,
+		Summary: "Set the chamber's debate period and per-member speaking time",
+		Usage:   "<debate minutes> [speaking minutes]",
+	}
+)
+
+type Motion struct {
+	text        string
+	moverID     string
+	seconderID  string
+	stage       int
+	speaking    map[string]time.Duration // Map from UserID to time spent holding the floor
+	floorHolder string                   // UserID of the member with the floor, if any
+	floorSince  time.Time
+}
+
+var Motions = make(map[string]*Motion)
+
+// Return the chamber's debate period and speaking time in minutes.
+func debateLimits(channelID string) (debate int, speaking int) {
+	chamber := Chambers[channelID]
+
+	debate, speaking = chamber.Debate, chamber.Speaking
+	if debate <= 0 {
+		debate = DEFAULT_DEBATE
+	}
+	if speaking <= 0 {
+		speaking = DEFAULT_SPEAKING
+	}
+
+	return debate, speaking
+}
+
+// Take the floor away from whoever holds it and add to their
+// speaking time. Return the UserID of the previous holder.
+func (motion *Motion) closeFloor() string {
+	holder := motion.floorHolder
+	if holder != "" {
+		motion.speaking[holder] += time.Since(motion.floorSince)
+		motion.floorHolder = ""
+	}
+
+	return holder
+}
+
+// Return a summary of the speaking time used in debate.
+func (motion *Motion) speakingSummary() string {
+	if len(motion.speaking) == 0 {
+		return "No member spoke in debate."
+	}
+
+	summary := "Speaking time used:"
+	for userID, used := range motion.speaking {
+		summary += "\n<@" + userID + ">: " + used.Round(time.Second).String()
+	}
+
+	return summary
+}
+
+// End debate on the channel's motion and put the question to a roll
+// call vote.
+func putQuestion(s *discordgo.Session, channelID string, duration int) error {
+	motion, ok := Motions[channelID]
+	if !ok {
+		return nil
+	}
+
+	motion.closeFloor()
+
+	// Keep the motion pending if the vote can't start, e.g. while
+	// another roll call is in progress, so it can be put again.
+	rollCall, err := startRollCall(s, channelID, duration, PassNumDefault, PassDenDefault, motion.text)
+	if rollCall == nil {
+		if err != nil {
+			return err
+		}
+		_, err = s.ChannelMessageSend(channelID, "The motion remains under debate. Use `"+PREFIX+
+			"previousquestion` to put it once the chamber is free.")
+		return err
+	}
+	delete(Motions, channelID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(channelID, "**Debate is closed.**\n"+motion.speakingSummary())
+	return err
+}
+
+func cmdMove(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsMember(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	if _, exists := Motions[m.ChannelID]; exists {
+		_, err := s.ChannelMessageSend(m.ChannelID, "A motion is already pending.")
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	motion := &Motion{
+		text:     strings.Join(args[1:], " "),
+		moverID:  m.Author.ID,
+		stage:    MOTION_SECONDING,
+		speaking: make(map[string]time.Duration),
+	}
+	Motions[m.ChannelID] = motion
+
+	go func() {
+		time.Sleep(DEFAULT_SECOND_TIMEOUT * time.Minute)
+
+		CommandMutex.Lock()
+		defer CommandMutex.Unlock()
+
+		if Motions[m.ChannelID] != motion || motion.stage != MOTION_SECONDING {
+			// Motion was seconded or replaced.
+			return
+		}
+
+		delete(Motions, m.ChannelID)
+		s.ChannelMessageSend(m.ChannelID, "The motion fails for lack of a second.")
+	}()
+
+	_, err := s.ChannelMessageSend(m.ChannelID, m.Author.Mention()+" moves: *"+motion.text+
+		"*\nIs there a second? (`"+PREFIX+"second`)")
+	return err
+}
+
+func cmdSecond(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsMember(s, m); !ok {
+		return err
+	}
+
+	motion, ok := Motions[m.ChannelID]
+	if !ok || motion.stage != MOTION_SECONDING {
+		_, err := s.ChannelMessageSend(m.ChannelID, "There is no motion waiting for a second.")
+		return err
+	}
+
+	if motion.moverID == m.Author.ID {
+		_, err := s.ChannelMessageSend(m.ChannelID, "You can't second your own motion.")
+		return err
+	}
+
+	debate, speaking := debateLimits(m.ChannelID)
+	motion.seconderID = m.Author.ID
+	motion.stage = MOTION_DEBATE
+
+	go func() {
+		time.Sleep(time.Duration(debate) * time.Minute)
+
+		CommandMutex.Lock()
+		defer CommandMutex.Unlock()
+
+		if Motions[m.ChannelID] != motion {
+			// Debate was already closed.
+			return
+		}
+
+		s.ChannelMessageSend(m.ChannelID, "**Time for debate has expired.**")
+		putQuestion(s, m.ChannelID, -1)
+	}()
+
+	_, err := s.ChannelMessageSend(m.ChannelID, "The motion is seconded by "+m.Author.Mention()+
+		". Debate is open for "+strconv.Itoa(debate)+" minutes with "+strconv.Itoa(speaking)+
+		" minutes per member. Use `"+PREFIX+"floor` to speak and `"+PREFIX+"yield` when finished.")
+	return err
+}
+
+func cmdFloor(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsMember(s, m); !ok {
+		return err
+	}
+
+	motion, ok := Motions[m.ChannelID]
+	if !ok || motion.stage != MOTION_DEBATE {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NO_DEBATE)
+		return err
+	}
+
+	if motion.floorHolder == m.Author.ID {
+		_, err := s.ChannelMessageSend(m.ChannelID, "You already have the floor.")
+		return err
+	} else if motion.floorHolder != "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "<@"+motion.floorHolder+"> currently has the floor.")
+		return err
+	}
+
+	_, speaking := debateLimits(m.ChannelID)
+	remaining := time.Duration(speaking)*time.Minute - motion.speaking[m.Author.ID]
+	if remaining <= 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "You have used all of your speaking time.")
+		return err
+	}
+
+	since := time.Now()
+	motion.floorHolder = m.Author.ID
+	motion.floorSince = since
+
+	go func() {
+		time.Sleep(remaining)
+
+		CommandMutex.Lock()
+		defer CommandMutex.Unlock()
+
+		if Motions[m.ChannelID] != motion || motion.floorHolder != m.Author.ID ||
+			motion.floorSince != since {
+			// Member already yielded.
+			return
+		}
+
+		motion.closeFloor()
+		s.ChannelMessageSend(m.ChannelID, m.Author.Mention()+", your speaking time has expired.")
+	}()
+
+	_, err := s.ChannelMessageSend(m.ChannelID, m.Author.Mention()+" has the floor for "+
+		remaining.Round(time.Second).String()+".")
+	return err
+}
+
+func cmdYield(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	motion, ok := Motions[m.ChannelID]
+	if !ok || motion.stage != MOTION_DEBATE {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NO_DEBATE)
+		return err
+	}
+
+	if motion.floorHolder != m.Author.ID {
+		_, err := s.ChannelMessageSend(m.ChannelID, "You don't have the floor.")
+		return err
+	}
+
+	motion.closeFloor()
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
+
+func cmdPreviousQuestion(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 0, 1); !ok {
+		return err
+	}
+
+	motion, ok := Motions[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NO_MOTION)
+		return err
+	} else if motion.stage != MOTION_DEBATE {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NO_DEBATE)
+		return err
+	}
+
+	duration := -1
+	args := strings.Split(m.Content, " ")
+	if len(args) == 2 {
+		var err error
+
+		duration, err = strconv.Atoi(args[1])
+		if err != nil {
+			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+	}
+
+	return putQuestion(s, m.ChannelID, duration)
+}
+
+func cmdSetDebate(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 2); !ok {
+		return err
+	}
+
+	chamber, ok := Chambers[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	debate, err := strconv.Atoi(args[1])
+	if err != nil || debate <= 0 {
+		_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+	chamber.Debate = debate
+
+	if len(args) == 3 {
+		speaking, err := strconv.Atoi(args[2])
+		if err != nil || speaking <= 0 {
+			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+		chamber.Speaking = speaking
+	}
+
+	Chambers[m.ChannelID] = chamber
+	if err := saveChambers(); err != nil {
+		return err
+	}
+
+	err = s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
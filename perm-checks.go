@@ -76,3 +76,26 @@ func checkAuthorIsClerk(s *discordgo.Session, m *discordgo.MessageCreate) (bool,
 	_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CLERK)
 	return false, err
 }
+
+// Return whether the author has the executive role, and send a message
+// if they don't.
+func checkAuthorIsExecutive(s *discordgo.Session, m *discordgo.MessageCreate) (bool, error) {
+	if Executive.Role == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "No executive role is set up.")
+		return false, err
+	}
+
+	return checkAuthorHasRole(s, m, Executive.Role)
+}
+
+// Return true if the author is a chamber member
+func checkAuthorIsMember(s *discordgo.Session, m *discordgo.MessageCreate) (bool, error) {
+	chamber, ok := Chambers[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return false, err
+	}
+
+	// Check if sender is a chamber member
+	return checkAuthorHasRole(s, m, chamber.MemberRole)
+}
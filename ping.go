@@ -20,7 +20,16 @@ var (
 	CMD_UNANIMOUS = Command{
 		Handler: unanimous,
 		Summary: "Record a unanimous agreement",
-		Usage:   "[minutes]",
+		Usage:   "[minutes] [request...]",
+	}
+	CMD_WITHDRAW = Command{
+		Handler: cmdWithdraw,
+		Summary: "Withdraw your objection to a unanimous consent request",
+	}
+	CMD_ESCALATE = Command{
+		Handler: cmdEscalate,
+		Summary: "Put an objected unanimous consent request to a roll call",
+		Usage:   "[minutes] [<ayes> <total>]",
 	}
 
 	AWAIT_UNANIMOUS = Await{
@@ -35,6 +44,20 @@ var (
 	}
 )
 
+type UnanimousRequest struct {
+	description  string            // What consent is being asked for, if given
+	objectors    []string          // UserIDs of members with standing objections
+	objections   map[string]string // Map from message ID to the UserID who objected in it
+	active       bool
+	passNum      int // Votes required if put to a roll call
+	passDen      int
+	autoEscalate bool // Whether to go straight to a roll call if objected
+
+	onAgreed func() error // Called once the request is agreed to
+}
+
+var UnanimousRequests = make(map[string]*UnanimousRequest)
+
 func ping(s *discordgo.Session, m *discordgo.MessageCreate, msg string) error {
 	role, err := chamberMemberRole(s, m.ChannelID)
 	if err != nil {
@@ -78,42 +101,124 @@ func unanimous(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return err
 	}
 
-	var duration int
-	var err error
+	duration := DEFAULT_UNANIMOUS
+	args := strings.Split(m.Content, " ")[1:]
 
-	args := strings.Split(m.Content, " ")
-	if len(args) > 2 {
-		_, err = s.ChannelMessageSend(m.ChannelID, MSG_TOO_MANY_ARGS)
-		return err
-	} else if len(args) == 2 {
-		// arg #1 is a number.
-		duration, err = strconv.Atoi(args[1])
-		if err != nil {
-			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
-			return err
+	if len(args) > 0 {
+		// Optional arg #1 is a number.
+		if minutes, err := strconv.Atoi(args[0]); err == nil {
+			duration = minutes
+			args = args[1:]
 		}
-	} else {
-		duration = DEFAULT_UNANIMOUS
 	}
 
+	return startUnanimous(s, m, duration, &UnanimousRequest{
+		description: strings.Join(args, " "),
+		passNum:     PassNumDefault,
+		passDen:     PassDenDefault,
+	})
+}
+
+// Return the UserIDs as a list of mentions.
+func mentionList(userIDs []string) string {
+	mentions := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		mentions[i] = "<@" + userID + ">"
+	}
+
+	return strings.Join(mentions, ", ")
+}
+
+// Ask the chamber for unanimous consent for the given number of
+// minutes, then act on the outcome once the time runs out.
+func startUnanimous(s *discordgo.Session, m *discordgo.MessageCreate, duration int,
+	request *UnanimousRequest) error {
+
 	if ok, err := addAwait(m.ChannelID, s, AWAIT_UNANIMOUS); !ok {
 		return err
 	}
+	request.active = true
+	UnanimousRequests[m.ChannelID] = request
 
-	err = ping(s, m, "Is there any objection?")
+	question := "Is there any objection?"
+	if request.description != "" {
+		question = "Unanimous consent is requested for " + request.description + ". " + question
+	}
+	err := ping(s, m, question)
 
 	go func() {
 		time.Sleep(time.Duration(duration) * time.Minute)
 
-		removed := removeAwait(m.ChannelID, AWAIT_UNANIMOUS_ID)
-		if removed {
+		CommandMutex.Lock()
+		defer CommandMutex.Unlock()
+
+		if UnanimousRequests[m.ChannelID] != request {
+			// The request was escalated or replaced.
+			return
+		}
+
+		if removed := removeAwait(m.ChannelID, AWAIT_UNANIMOUS_ID); !removed {
+			return
+		}
+		request.active = false
+
+		if len(request.objectors) == 0 {
+			delete(UnanimousRequests, m.ChannelID)
 			s.ChannelMessageSend(m.ChannelID, "No objection.")
+
+			if request.onAgreed != nil {
+				request.onAgreed()
+			}
+			return
+		}
+
+		s.ChannelMessageSend(m.ChannelID, "With objection from "+mentionList(request.objectors)+".")
+		if request.autoEscalate {
+			escalateUnanimous(s, m.ChannelID, request, -1, request.passNum, request.passDen)
+		} else {
+			s.ChannelMessageSend(m.ChannelID, "The Speaker may put the request to a roll call with `"+
+				PREFIX+"escalate`.")
 		}
 	}()
 
 	return err
 }
 
+// End a unanimous consent request and put it to a roll call instead.
+func escalateUnanimous(s *discordgo.Session, channelID string, request *UnanimousRequest,
+	duration int, passNum int, passDen int) error {
+
+	// Keep the request if the vote can't start, e.g. while another roll
+	// call is in progress, so it can be escalated again.
+	rollCall, err := startRollCall(s, channelID, duration, passNum, passDen, request.description)
+	if rollCall == nil {
+		if err != nil {
+			return err
+		}
+		_, err = s.ChannelMessageSend(channelID, "The Speaker may put the request to a roll call with `"+
+			PREFIX+"escalate` once the chamber is free.")
+		return err
+	}
+
+	delete(UnanimousRequests, channelID)
+	if request.active {
+		removeAwait(channelID, AWAIT_UNANIMOUS_ID)
+		request.active = false
+	}
+
+	if request.onAgreed == nil {
+		return err
+	}
+
+	rollCall.onResult = func(passed bool) error {
+		if !passed {
+			return nil
+		}
+		return request.onAgreed()
+	}
+	return err
+}
+
 func awaitUnanimous(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	chamber, ok := Chambers[m.ChannelID]
 	if !ok {
@@ -122,6 +227,11 @@ func awaitUnanimous(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return ERR_NOT_A_CHAMBER
 	}
 
+	request, ok := UnanimousRequests[m.ChannelID]
+	if !ok {
+		return nil
+	}
+
 	member, err := s.GuildMember(m.GuildID, m.Author.ID)
 	if err != nil {
 		return err
@@ -132,14 +242,91 @@ func awaitUnanimous(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return nil
 	}
 
-	msg := strings.ToLower(m.Content)
+	if isObjection(m.Content) {
+		if request.objections == nil {
+			request.objections = make(map[string]string)
+		}
+		request.objections[m.ID] = m.Author.ID
+
+		for _, objector := range request.objectors {
+			if objector == m.Author.ID {
+				// Already objecting.
+				return nil
+			}
+		}
+
+		// Member objected; record the objection.
+		request.objectors = append(request.objectors, m.Author.ID)
+		_, err = s.ChannelMessageSend(m.ChannelID, "Objection from "+m.Author.Mention()+
+			" is noted. It may be withdrawn with `"+PREFIX+"withdraw` before time expires.")
+		return err
+	}
+
+	return nil
+}
+
+// Return whether the message objects to a unanimous consent request.
+func isObjection(content string) bool {
+	msg := strings.ToLower(content)
 	for _, objection := range OBJECTIONS {
-		if len(msg) >= len(objection) && msg[:len(objection)] == objection {
-			// Member objected; give it the objection.
-			_, err = s.ChannelMessageSend(m.ChannelID, "with objection")
-			removeAwait(m.ChannelID, AWAIT_UNANIMOUS_ID)
+		if strings.HasPrefix(msg, objection) {
+			return true
 		}
 	}
 
+	return false
+}
+
+// Withdraw the member's objection. Return whether they had objected.
+func (r *UnanimousRequest) withdraw(userID string) bool {
+	for i, objector := range r.objectors {
+		if objector == userID {
+			r.objectors = append(r.objectors[:i], r.objectors[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func cmdWithdraw(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	request, ok := UnanimousRequests[m.ChannelID]
+	if !ok || !request.active {
+		_, err := s.ChannelMessageSend(m.ChannelID, "There is no unanimous consent request in progress.")
+		return err
+	}
+
+	if request.withdraw(m.Author.ID) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "Objection from "+m.Author.Mention()+
+			" is withdrawn.")
+		return err
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, "You haven't objected.")
 	return err
 }
+
+func cmdEscalate(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 0, 3); !ok {
+		return err
+	}
+
+	request, ok := UnanimousRequests[m.ChannelID]
+	if !ok || len(request.objectors) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "There is no objected unanimous consent request.")
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	duration, passNum, passDen, err := parseCallArgs(args, request.passNum, request.passDen)
+	if err != nil {
+		_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	return escalateUnanimous(s, m.ChannelID, request, duration, passNum, passDen)
+}
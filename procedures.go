@@ -0,0 +1,125 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"strings"
+)
+
+const (
+	STATUS_CLOTURE    = "cloture"
+	STATUS_DISCHARGED = "discharged"
+
+	ClotureNum   = 3
+	ClotureDen   = 5
+	DischargeNum = PassNumDefault
+	DischargeDen = PassDenDefault
+)
+
+var (
+	CMD_CLOTURE = Command{
+		Handler: cmdCloture,
+		Summary: "Invoke cloture on a docketed item by unanimous consent, or failing that, a 3/5 roll call",
+		Usage:   "<MOTION>",
+	}
+	CMD_PASSAGE = Command{
+		Handler: cmdPassage,
+		Summary: "Pass a docketed item by unanimous consent, or failing that, a majority roll call",
+		Usage:   "<MOTION>",
+	}
+	CMD_DISCHARGE = Command{
+		Handler: cmdDischarge,
+		Summary: "Discharge a docketed item from committee by unanimous consent, or failing that, a roll call",
+		Usage:   "<MOTION> <committee>",
+	}
+)
+
+// Seek unanimous consent for a procedure on a docketed item, falling
+// back to a roll call with the given threshold if anyone objects. The
+// item takes the given status if the procedure is agreed to, and an
+// item agreed to pass goes on wherever the chamber routes passed items.
+func runProcedure(s *discordgo.Session, m *discordgo.MessageCreate, question string,
+	passNum int, passDen int, identifier string, status string, agreed string) error {
+
+	return startUnanimous(s, m, DEFAULT_UNANIMOUS, &UnanimousRequest{
+		description:  question,
+		passNum:      passNum,
+		passDen:      passDen,
+		autoEscalate: true,
+		onAgreed: func() error {
+			if err := setItemStatus(s, m, identifier, status); err != nil {
+				return err
+			}
+
+			if _, err := s.ChannelMessageSend(m.ChannelID, agreed); err != nil {
+				return err
+			}
+
+			if status == STATUS_PASSED {
+				return routeItem(s, m, identifier)
+			}
+			return nil
+		},
+	})
+}
+
+func cmdCloture(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_CLOTURE, false); !ok {
+		return err
+	}
+
+	return runProcedure(s, m, "invoking cloture on "+identifier, ClotureNum, ClotureDen,
+		identifier, STATUS_CLOTURE, "Cloture is invoked on "+identifier+".")
+}
+
+func cmdPassage(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_PASSED, false); !ok {
+		return err
+	}
+
+	return runProcedure(s, m, "passing "+identifier, PassNumDefault, PassDenDefault,
+		identifier, STATUS_PASSED, identifier+" is passed.")
+}
+
+func cmdDischarge(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 2, 2); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+	committee := args[2]
+
+	if ok, err := checkStatusChange(s, m, identifier, STATUS_DISCHARGED, false); !ok {
+		return err
+	}
+
+	return runProcedure(s, m, "discharging "+identifier+" from the "+committee+" committee",
+		DischargeNum, DischargeDen, identifier, STATUS_DISCHARGED,
+		identifier+" is discharged from the "+committee+" committee and brought to the floor.")
+}
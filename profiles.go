@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"os"
+	"strings"
+)
+
+const (
+	TITLE_NONE = "none"
+)
+
+var (
+	CMD_SETTITLE = Command{
+		Handler: cmdSetTitle,
+		Summary: "Set how the bot addresses you, with an optional display name",
+		Usage:   "<title|none|default> [display name...|default]",
+	}
+	CMD_SETDEFAULTTITLE = Command{
+		Handler: cmdSetDefaultTitle,
+		Summary: "Set the title used for chamber members who haven't chosen one",
+		Usage:   "<title|none>",
+	}
+
+	TITLES = []string{
+		"Senator",
+		"Rep.",
+		"Delegate",
+		"Mx.",
+		"Mr.",
+		"Ms.",
+		"Mrs.",
+		"Dr.",
+		TITLE_NONE,
+	}
+)
+
+// How a member wants to be addressed. Empty fields fall back to the
+// chamber's default title and the member's username.
+type Profile struct {
+	Title string `json:"title"`
+	Name  string `json:"name"`
+}
+
+func saveProfiles() error {
+	file, err := os.Create(PROFILE_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(Profiles)
+
+	return file.Close()
+}
+
+// Return the canonical spelling of a title, and whether it's valid.
+func parseTitle(title string) (string, bool) {
+	for _, v := range TITLES {
+		if strings.EqualFold(title, v) || strings.EqualFold(title+".", v) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// Return the title to address the user by in the channel's chamber,
+// which may be empty.
+func memberTitle(channelID string, userID string) string {
+	title := Profiles[userID].Title
+	if title == "" {
+		title = Chambers[channelID].Title
+	}
+
+	if title == TITLE_NONE {
+		return ""
+	}
+	return title
+}
+
+// Return the name the user wants to be called by.
+func displayName(user *discordgo.User) string {
+	if name := Profiles[user.ID].Name; name != "" {
+		return name
+	}
+
+	return user.Username
+}
+
+// Return the user's title and display name.
+func address(channelID string, user *discordgo.User) string {
+	if title := memberTitle(channelID, user.ID); title != "" {
+		return title + " " + displayName(user)
+	}
+
+	return displayName(user)
+}
+
+// Return the user's title and a mention of them.
+func addressMention(channelID string, userID string) string {
+	if title := memberTitle(channelID, userID); title != "" {
+		return title + " <@" + userID + ">"
+	}
+
+	return "<@" + userID + ">"
+}
+
+func cmdSetTitle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 1, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	profile := Profiles[m.Author.ID]
+
+	if args[1] == "default" {
+		profile.Title = ""
+	} else if title, ok := parseTitle(args[1]); ok {
+		profile.Title = title
+	} else {
+		_, err := s.ChannelMessageSend(m.ChannelID, "Titles can be one of: "+
+			strings.Join(TITLES, ", ")+", or default.")
+		return err
+	}
+
+	// Keep any display name set earlier unless a new one is given.
+	if name := strings.Join(args[2:], " "); name == "default" {
+		profile.Name = ""
+	} else if name != "" {
+		profile.Name = name
+	}
+	Profiles[m.Author.ID] = profile
+
+	if err := saveProfiles(); err != nil {
+		return err
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, "You will be addressed as "+
+		address(m.ChannelID, m.Author)+".")
+	return err
+}
+
+func cmdSetDefaultTitle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	chamber, ok := Chambers[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	title, ok := parseTitle(args[1])
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "Titles can be one of: "+
+			strings.Join(TITLES, ", ")+".")
+		return err
+	}
+
+	chamber.Title = title
+	Chambers[m.ChannelID] = chamber
+	if err := saveChambers(); err != nil {
+		return err
+	}
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"log"
+)
+
+// Return the vote given by reacting with the emoji in the guild.
+func reactVote(guildID string, emoji string) (Vote, bool) {
+	vocab := vocabulary(guildID)
+	for _, vote := range []Vote{For, Against, Abstained} {
+		if vocab.words(vote).React == emoji {
+			return vote, true
+		}
+	}
+
+	return -1, false
+}
+
+// Add the vote reactions to the roll call's status message, so members
+// can vote by clicking them.
+func addVoteReacts(s *discordgo.Session, rollCall *RollCall) error {
+	vocab := vocabulary(rollCall.guildID)
+	for _, vote := range []Vote{For, Against, Abstained} {
+		if err := s.MessageReactionAdd(rollCall.channelID, rollCall.messageID, vocab.words(vote).React); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Return the active roll call whose status message was reacted to, and
+// the vote the reaction gives.
+func reactedRollCall(s *discordgo.Session, r *discordgo.MessageReaction) (*RollCall, Vote, bool) {
+	if r.UserID == s.State.User.ID {
+		// Ignore our own reactions.
+		return nil, -1, false
+	}
+
+	rollCall, ok := RollCalls[r.ChannelID]
+	if !ok || !rollCall.active || rollCall.messageID != r.MessageID || !rollCall.isMember(r.UserID) {
+		return nil, -1, false
+	}
+
+	vote, ok := reactVote(rollCall.guildID, r.Emoji.APIName())
+	return rollCall, vote, ok
+}
+
+func messageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	CommandMutex.Lock()
+	defer CommandMutex.Unlock()
+
+	if err := reactionVote(s, r.MessageReaction); err != nil {
+		log.Println("Error handling vote reaction:", err)
+	}
+}
+
+func messageReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	CommandMutex.Lock()
+	defer CommandMutex.Unlock()
+
+	if err := reactionRetract(s, r.MessageReaction); err != nil {
+		log.Println("Error handling removed vote reaction:", err)
+	}
+}
+
+// Record the vote given by reacting to a roll call's status message,
+// and remove the member's other vote reactions.
+func reactionVote(s *discordgo.Session, r *discordgo.MessageReaction) error {
+	rollCall, vote, ok := reactedRollCall(s, r)
+	if !ok {
+		return nil
+	}
+
+	reacts := rollCall.voteReacts[r.UserID]
+	if reacts == nil {
+		reacts = make(map[Vote]bool)
+		rollCall.voteReacts[r.UserID] = reacts
+	}
+	reacts[vote] = true
+
+	if ok, reason := rollCall.recordVote(r.UserID, vote, ""); !ok {
+		if err := s.MessageReactionRemove(r.ChannelID, r.MessageID, r.Emoji.APIName(), r.UserID); err != nil {
+			return err
+		}
+
+		_, err := s.ChannelMessageSend(r.ChannelID, "<@"+r.UserID+"> "+reason)
+		return err
+	}
+
+	// The reaction now gives the member's vote, not any message.
+	delete(rollCall.voteMessages, r.UserID)
+	updateRollCallStatus(s, r.ChannelID, rollCall)
+
+	// Only remove reactions we've seen added. A reaction whose event
+	// hasn't been handled yet will replace this vote when it is.
+	vocab := vocabulary(rollCall.guildID)
+	for other := range reacts {
+		if other == vote {
+			continue
+		}
+
+		err := s.MessageReactionRemove(r.ChannelID, r.MessageID, vocab.words(other).React, r.UserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return stopOnQuorum(s, rollCall)
+}
+
+// Withdraw the vote given by a reaction to a roll call's status message
+// once the member removes it.
+func reactionRetract(s *discordgo.Session, r *discordgo.MessageReaction) error {
+	rollCall, vote, ok := reactedRollCall(s, r)
+	if !ok {
+		return nil
+	}
+	delete(rollCall.voteReacts[r.UserID], vote)
+
+	// Removing a reaction other than the member's current vote, such as
+	// one we removed ourselves, changes nothing.
+	if current, voted := rollCall.votes[r.UserID]; !voted || current != vote {
+		return nil
+	}
+
+	return retractMessageVote(s, rollCall, r.UserID, "")
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	CMD_SETREMINDERS = Command{
+		Handler: cmdSetReminders,
+		Summary: "Set when non-voters are reminded during a timed roll call, as fractions of the voting period",
+		Usage:   "<off|fraction ...>",
+	}
+	CMD_REMINDERS = Command{
+		Handler: cmdReminders,
+		Summary: "Turn vote reminders by DM on or off for yourself",
+		Usage:   "<on|off>",
+	}
+)
+
+func saveDMOptOuts() error {
+	file, err := os.Create(OPTOUT_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(DMOptOuts)
+
+	return file.Close()
+}
+
+// Return whether the user has opted out of reminders by DM.
+func hasOptedOut(userID string) bool {
+	for _, v := range DMOptOuts {
+		if v == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return the duration as a whole number of minutes.
+func formatMinutes(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes == 1 {
+		return "1 minute"
+	}
+
+	return strconv.Itoa(minutes) + " minutes"
+}
+
+// Start timers for each of the chamber's reminders during a roll call
+// of the given number of minutes.
+func scheduleReminders(s *discordgo.Session, channelID string, rollCall *RollCall, duration int) {
+	for _, fraction := range Chambers[channelID].Reminders {
+		delay := time.Duration(fraction * float64(time.Duration(duration)*time.Minute))
+
+		go func() {
+			time.Sleep(delay)
+
+			CommandMutex.Lock()
+			defer CommandMutex.Unlock()
+
+			if RollCalls[channelID] != rollCall || !rollCall.active || !rollCall.timerActive {
+				// Roll call has ended or been replaced.
+				return
+			}
+
+			remindNonVoters(s, channelID, rollCall)
+		}()
+	}
+}
+
+// Remind every member who hasn't voted yet, by DM unless they opted
+// out or can't be reached, in which case they're mentioned instead.
+func remindNonVoters(s *discordgo.Session, channelID string, rollCall *RollCall) error {
+	remaining := formatMinutes(time.Until(rollCall.deadline))
+	mentions := ""
+
+	for _, userID := range rollCall.members {
+		if _, voted := rollCall.votes[userID]; voted {
+			continue
+		}
+
+		if !hasOptedOut(userID) {
+			dm, err := s.UserChannelCreate(userID)
+			if err == nil {
+				_, err = s.ChannelMessageSend(dm.ID, "Reminder: you haven't voted in the roll call in <#"+
+					channelID+">, with "+remaining+" left.")
+			}
+			if err == nil {
+				continue
+			}
+		}
+
+		mentions += "<@" + userID + "> "
+	}
+
+	if mentions == "" {
+		return nil
+	}
+
+	_, err := s.ChannelMessageSend(channelID, mentions+"you have not voted yet, with "+
+		remaining+" left.")
+	return err
+}
+
+func cmdSetReminders(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	chamber, ok := Chambers[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	var reminders []float64
+
+	if len(args) != 2 || args[1] != "off" {
+		for _, arg := range args[1:] {
+			fraction, err := strconv.ParseFloat(arg, 64)
+			if err != nil || fraction <= 0 || fraction >= 1 {
+				_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+				return err
+			}
+
+			reminders = append(reminders, fraction)
+		}
+	}
+
+	chamber.Reminders = reminders
+	Chambers[m.ChannelID] = chamber
+	if err := saveChambers(); err != nil {
+		return err
+	}
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
+
+func cmdReminders(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	switch args[1] {
+	case "on":
+		for i := 0; i < len(DMOptOuts); i++ {
+			if DMOptOuts[i] == m.Author.ID {
+				DMOptOuts = append(DMOptOuts[:i], DMOptOuts[i+1:]...)
+				i--
+			}
+		}
+	case "off":
+		if !hasOptedOut(m.Author.ID) {
+			DMOptOuts = append(DMOptOuts, m.Author.ID)
+		}
+	default:
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	if err := saveDMOptOuts(); err != nil {
+		return err
+	}
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
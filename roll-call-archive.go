@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"os"
+	"time"
+)
+
+const (
+	ARCHIVE_LIST_LENGTH = 10
+)
+
+var (
+	CMD_ARCHIVE = Command{
+		Handler: cmdArchive,
+		Summary: "List the chamber's most recent archived roll calls and their numbers",
+	}
+)
+
+// A finished roll call, as kept in the archive.
+type RollCallRecord struct {
+	Motion  string          `json:"motion"`
+	Start   time.Time       `json:"start"`
+	End     time.Time       `json:"end"`
+	Members []string        `json:"members"`
+	Excused []string        `json:"excused"`
+	Votes   map[string]Vote `json:"votes"`
+	PassNum int             `json:"passnum"`
+	PassDen int             `json:"passden"`
+	Passed  bool            `json:"passed"`
+
+	inProgress bool // Whether the record is of a vote still open, so has no result yet
+}
+
+func saveArchive() error {
+	file, err := os.Create(ARCHIVE_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(Archive)
+
+	return file.Close()
+}
+
+// Return a record of the roll call as it stands.
+func (r *RollCall) record(passed bool) RollCallRecord {
+	votes := make(map[string]Vote, len(r.votes))
+	for userID, vote := range r.votes {
+		votes[userID] = vote
+	}
+
+	return RollCallRecord{
+		Motion:  r.motion,
+		Start:   r.start,
+		End:     time.Now(),
+		Members: r.members,
+		Excused: r.excused,
+		Votes:   votes,
+		PassNum: r.passNum,
+		PassDen: r.passDen,
+		Passed:  passed,
+	}
+}
+
+// Save the result of a stopped roll call to the chamber's archive. A
+// resumed roll call replaces its earlier record.
+func archiveRollCall(rollCall *RollCall, passed bool) error {
+	records := Archive[rollCall.channelID]
+	record := rollCall.record(passed)
+
+	if rollCall.archived > 0 {
+		records[rollCall.archived-1] = record
+	} else {
+		records = append(records, record)
+		rollCall.archived = len(records)
+	}
+
+	Archive[rollCall.channelID] = records
+	return saveArchive()
+}
+
+// Return a one-line summary of the roll call's outcome.
+func (r RollCallRecord) summary() string {
+	var ayes, nays, absents int
+	for _, vote := range r.Votes {
+		switch vote {
+		case For:
+			ayes++
+		case Against:
+			nays++
+		case Abstained:
+			absents++
+		}
+	}
+
+	result := "not agreed to"
+	if r.Passed {
+		result = "agreed to"
+	}
+
+	return fmt.Sprintf("Yeas %d - Nays %d - Present %d, %s on %s", ayes, nays, absents, result,
+		r.End.UTC().Format("2006-01-02 15:04"))
+}
+
+func cmdArchive(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	records := Archive[m.ChannelID]
+	if len(records) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NO_RECENT_CALL)
+		return err
+	}
+
+	first := len(records) - ARCHIVE_LIST_LENGTH
+	if first < 0 {
+		first = 0
+	}
+
+	response := "*Archived roll calls:*\n"
+	for i := len(records) - 1; i >= first; i-- {
+		record := records[i]
+
+		result := "not agreed to"
+		if record.Passed {
+			result = "agreed to"
+		}
+
+		motion := record.Motion
+		if motion == "" {
+			motion = "(no motion stated)"
+		}
+
+		response += fmt.Sprintf("\n**#%d** %s: %s, %s", i+1,
+			record.End.UTC().Format("2006-01-02 15:04"), motion, result)
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, response)
+	return err
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"strings"
+	"time"
+)
+
+const (
+	STATUS_EDIT_INTERVAL = 3 * time.Second // Minimum time between edits of a status message
+	STATUS_MORE_RESERVE  = 32              // Room kept for the count of votes left off a long status
+)
+
+// Return the live status of the roll call, listing every member's
+// vote and the running tally. In chambers too large for one message,
+// only the votes cast are listed and the rest are counted.
+func (r *RollCall) status() string {
+	header := ""
+	if r.motion != "" {
+		header += "**The question is on the motion:** " + r.motion + "\n\n"
+	}
+
+	var voted, roster string
+	notVoting := 0
+	for _, userID := range r.members {
+		line := addressMention(r.channelID, userID) + ": "
+		if vote, ok := r.votes[userID]; ok {
+			line += vocabulary(r.guildID).words(vote).React + " " + vote.String() + "\n"
+			voted += line
+		} else {
+			line += "*not voting*\n"
+			notVoting++
+		}
+		roster += line
+	}
+	for _, userID := range r.excused {
+		roster += addressMention(r.channelID, userID) + ": *excused*\n"
+	}
+
+	footer := r.tally()
+	if len(header)+len(roster)+len(footer) <= MESSAGE_MAX {
+		return header + roster + footer
+	}
+
+	counts := fmt.Sprintf("*%d not voting, %d excused*\n", notVoting, len(r.excused))
+	budget := MESSAGE_MAX - len(header) - len(counts) - len(footer) - STATUS_MORE_RESERVE
+	if budget < 0 {
+		budget = 0
+	}
+	if len(voted) > budget {
+		cut := strings.LastIndex(voted[:budget], "\n") + 1
+		more := strings.Count(voted[cut:], "\n")
+		voted = voted[:cut] + fmt.Sprintf("*…and %d more votes*\n", more)
+	}
+
+	return header + voted + counts + footer
+}
+
+// Return the running tally, quorum and clock of the roll call.
+func (r *RollCall) tally() string {
+	ayes, nays, absents := r.countVotes()
+	content := fmt.Sprintf("\n**Yeas %d - Nays %d**", ayes, nays)
+	if absents > 0 {
+		content += fmt.Sprintf(" with %d present", absents)
+	}
+	content += ", with " + r.PassReqtoa() + " required to vote in the affirmative.\n"
+
+	content += fmt.Sprintf("Quorum is %d; %d votes cast, ", r.quorum, len(r.votes))
+	if r.QuorumMet() {
+		content += "quorum is met.\n"
+	} else {
+		content += "quorum is not met.\n"
+	}
+
+	if !r.active {
+		content += "**Voting has closed.**"
+	} else if r.timerActive {
+		content += "The clock is on with " + formatMinutes(time.Until(r.deadline)) + " left."
+	} else {
+		content += "The vote is on."
+	}
+
+	return content
+}
+
+// Edit the roll call's status message to match its current state,
+// deferring the edit if the message was edited too recently.
+func updateRollCallStatus(s *discordgo.Session, channelID string, rollCall *RollCall) {
+	if rollCall.messageID == "" || rollCall.editPending {
+		return
+	}
+
+	wait := STATUS_EDIT_INTERVAL - time.Since(rollCall.lastEdit)
+	if wait <= 0 {
+		editRollCallStatus(s, channelID, rollCall)
+		return
+	}
+
+	rollCall.editPending = true
+	go func() {
+		time.Sleep(wait)
+
+		CommandMutex.Lock()
+		defer CommandMutex.Unlock()
+
+		rollCall.editPending = false
+		editRollCallStatus(s, channelID, rollCall)
+	}()
+}
+
+func editRollCallStatus(s *discordgo.Session, channelID string, rollCall *RollCall) error {
+	rollCall.lastEdit = time.Now()
+	_, err := s.ChannelMessageEdit(channelID, rollCall.messageID, rollCall.status())
+	return err
+}
+
+// Refresh the roll call's status message every minute while the clock
+// is on.
+func startCountdown(s *discordgo.Session, channelID string, rollCall *RollCall) {
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+
+			CommandMutex.Lock()
+			running := RollCalls[channelID] == rollCall && rollCall.active && rollCall.timerActive
+			if running {
+				updateRollCallStatus(s, channelID, rollCall)
+			}
+			CommandMutex.Unlock()
+
+			if !running {
+				return
+			}
+		}
+	}()
+}
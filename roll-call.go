@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"github.com/bwmarrin/discordgo"
+	"log"
 	"math"
 	"strconv"
 	"strings"
@@ -29,7 +30,7 @@ const (
 var (
 	CMD_CALL = Command{
 		Handler: cmdCall,
-		Summary: "Start a roll-call vote for the chamber",
+		Summary: "Start a roll-call vote for the chamber. Members vote by message or by reacting to the roll call",
 		Usage:   "[minutes] [<ayes> <total>]",
 	}
 	CMD_ENDVOTING = Command{
@@ -48,6 +49,7 @@ var (
 	CMD_GETVOTES = Command{
 		Handler: cmdGetVotes,
 		Summary: "List the votes of the current active or last active roll call",
+		Usage:   "[--history]",
 	}
 	CMD_SETVOTES = Command{
 		Handler: cmdSetVotes,
@@ -59,32 +61,6 @@ var (
 		ID:      AWAIT_CALL_ID,
 		AddErr:  "A roll-call vote is already in progress",
 	}
-
-	VOTE_REACTS = map[Vote]string{
-		For:       "\u2705",
-		Against:   "\u274c",
-		Abstained: "\u2796",
-	}
-
-	VOTE_FOR = []string{
-		"yea",
-		"aye",
-		"jeff",
-		"yeet",
-		"non't",
-		"nont",
-	}
-	VOTE_AGAINST = []string{
-		"nay",
-		"nae",
-		"gay",
-		"yesn't",
-		"yesnt",
-	}
-	VOTE_ABSTAINED = []string{
-		"abstain",
-		"present",
-	}
 )
 
 type Vote int
@@ -105,13 +81,36 @@ func (v Vote) String() string {
 }
 
 type RollCall struct {
-	votes       map[string]Vote // Map from UserID to vote
-	members     []string        // List of UserID's of chamber members since the start of the vote
-	quorum      int             // Pre-calculated minimum number of votes to call quorum
-	timerActive bool
-	passNum     int
-	passDen     int
-	active      bool
+	channelID    string
+	guildID      string
+	votes        map[string]Vote          // Map from UserID to vote
+	voteMessages map[string]string        // Map from UserID to the ID of the message that gave their vote
+	voteReacts   map[string]map[Vote]bool // Vote reactions each member has on the status message
+	history      []VoteChange
+	members      []string // List of UserID's of chamber members since the start of the vote
+	excused      []string // List of UserID's of chamber members on leave during the vote
+	quorum       int      // Pre-calculated minimum number of votes to call quorum
+	timerActive  bool
+	clockStopped bool      // Whether the clock has run out or been stopped
+	deadline     time.Time // When the clock runs out, if timerActive
+	passNum      int
+	passDen      int
+	active       bool
+	motion       string // Question being voted on, if stated through the bot
+	messageID    string // ID of the live status message
+	lastEdit     time.Time
+	editPending  bool
+	start        time.Time
+	archived     int // Position in the chamber's archive plus one, once archived
+
+	users    map[string]*discordgo.User // Members and excused members by UserID, for the roster
+	onResult func(passed bool) error    // Called with the outcome the first time the vote stops
+}
+
+// Return the minimum number of votes for quorum in a chamber with the
+// given number of members.
+func quorumOf(members int) int {
+	return int(math.Floor(float64(members)/2.0)) + 1
 }
 
 // Return whether a roll call vote is active in the given channel.
@@ -145,33 +144,6 @@ func (r RollCall) QuorumMet() bool {
 	return len(r.votes) >= r.quorum
 }
 
-// Interprets a string content and gives the corresponding vote. If s
-// contains an invalid phrase, it instead returns strconv.ErrSyntax
-// and -1 as a vote.
-func parseVote(content string) (Vote, error) {
-	content = strings.ToLower(content)
-
-	for _, aye := range VOTE_FOR {
-		if strings.HasPrefix(content, aye) {
-			return For, nil
-		}
-	}
-
-	for _, nay := range VOTE_AGAINST {
-		if strings.HasPrefix(content, nay) {
-			return Against, nil
-		}
-	}
-
-	for _, present := range VOTE_ABSTAINED {
-		if strings.HasPrefix(content, present) {
-			return Abstained, nil
-		}
-	}
-
-	return -1, strconv.ErrSyntax
-}
-
 func (r *RollCall) countVotes() (ayes int, nays int, absents int) {
 	for _, vote := range r.votes {
 		switch vote {
@@ -187,6 +159,61 @@ func (r *RollCall) countVotes() (ayes int, nays int, absents int) {
 	return ayes, nays, absents
 }
 
+// Return the member, as fetched when the vote started.
+func (r *RollCall) user(s *discordgo.Session, userID string) (*discordgo.User, error) {
+	if user, ok := r.users[userID]; ok {
+		return user, nil
+	}
+
+	user, err := s.User(userID)
+	if err != nil {
+		return nil, err
+	}
+	if r.users == nil {
+		r.users = make(map[string]*discordgo.User)
+	}
+	r.users[userID] = user
+	return user, nil
+}
+
+// Return the names of the members voting each way.
+func (r *RollCall) roster(s *discordgo.Session) (string, error) {
+	names := make(map[Vote][]string)
+	for _, userID := range r.members {
+		vote, ok := r.votes[userID]
+		if !ok {
+			continue
+		}
+
+		user, err := r.user(s, userID)
+		if err != nil {
+			return "", err
+		}
+		names[vote] = append(names[vote], address(r.channelID, user))
+	}
+
+	roster := ""
+	for _, vote := range []Vote{For, Against, Abstained} {
+		if len(names[vote]) > 0 {
+			roster += "**" + vote.String() + ":** " + strings.Join(names[vote], ", ") + "\n"
+		}
+	}
+
+	var excused []string
+	for _, userID := range r.excused {
+		user, err := r.user(s, userID)
+		if err != nil {
+			return "", err
+		}
+		excused = append(excused, address(r.channelID, user))
+	}
+	if len(excused) > 0 {
+		roster += "**Excused:** " + strings.Join(excused, ", ") + "\n"
+	}
+
+	return roster, nil
+}
+
 // Stop the roll call vote, remove its associated await, and return
 // whether successful and any corresponding errors.
 func stopRollCall(s *discordgo.Session, channelID string) (bool, error) {
@@ -202,6 +229,7 @@ func stopRollCall(s *discordgo.Session, channelID string) (bool, error) {
 	)
 
 	rollCall.active = false
+	updateRollCallStatus(s, channelID, rollCall)
 	ayes, nays, absents := rollCall.countVotes()
 
 	if len(rollCall.votes) == 0 {
@@ -210,7 +238,11 @@ func stopRollCall(s *discordgo.Session, channelID string) (bool, error) {
 		motionPassed = false
 	}
 
-	reply := "The Yeas and Nays are " +
+	reply := ""
+	if rollCall.motion != "" {
+		reply += "On the motion *" + rollCall.motion + "*: "
+	}
+	reply += "The Yeas and Nays are " +
 		strconv.Itoa(ayes) + " - " + strconv.Itoa(nays)
 	if absents > 0 {
 		reply += " with " + strconv.Itoa(absents) + " absentions"
@@ -223,176 +255,223 @@ func stopRollCall(s *discordgo.Session, channelID string) (bool, error) {
 		reply += "not able to vote in the affirmative, the motion is not agreed to."
 	}
 
-	_, err := s.ChannelMessageSend(channelID, reply)
-	return true, err
-}
+	if err := archiveRollCall(rollCall, motionPassed); err != nil {
+		return true, err
+	}
 
-func cmdCall(s *discordgo.Session, m *discordgo.MessageCreate) error {
-	var (
-		args     = strings.Split(m.Content, " ")
-		passNum  = PassNumDefault
-		passDen  = PassDenDefault
-		duration = -1
-	)
+	roster, err := rollCall.roster(s)
+	if err != nil {
+		return true, err
+	}
+	reply += "\n\n" + roster
 
-	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
-		return err
+	if _, err := s.ChannelMessageSend(channelID, reply); err != nil {
+		return true, err
 	}
 
-	if len(args) > 4 {
-		_, err := s.ChannelMessageSend(m.ChannelID, MSG_TOO_MANY_ARGS)
-		return err
-	} else if len(args) == 4 {
-		// ;call <len> <num> <den>
-		var err error
+	// Act on the outcome only once, even if the vote is resumed and
+	// stopped again.
+	if onResult := rollCall.onResult; onResult != nil {
+		rollCall.onResult = nil
+		return true, onResult(motionPassed)
+	}
 
-		duration, err = strconv.Atoi(args[1])
-		if err != nil {
-			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
-			return err
-		}
+	return true, nil
+}
 
-		passNum, err = strconv.Atoi(args[2])
-		if err != nil {
-			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
-			return err
-		}
+// Parse the "[minutes] [<ayes> <total>]" arguments following a roll
+// call command, defaulting to an untimed vote with the given
+// requirements.
+func parseCallArgs(args []string, passNum int, passDen int) (duration int, num int, den int, err error) {
+	duration, num, den = -1, passNum, passDen
 
-		passDen, err = strconv.Atoi(args[3])
-		if err != nil {
-			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
-			return err
+	switch len(args) {
+	case 4:
+		// ;call <len> <num> <den>
+		if duration, err = strconv.Atoi(args[1]); err != nil {
+			return
 		}
-	} else if len(args) == 3 {
-		// ;call <num> <den>
-		var err error
-
-		passNum, err = strconv.Atoi(args[1])
-		if err != nil {
-			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
-			return err
+		if num, err = strconv.Atoi(args[2]); err != nil {
+			return
 		}
-
-		passDen, err = strconv.Atoi(args[2])
-		if err != nil {
-			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
-			return err
+		den, err = strconv.Atoi(args[3])
+	case 3:
+		// ;call <num> <den>
+		if num, err = strconv.Atoi(args[1]); err != nil {
+			return
 		}
-	} else if len(args) == 2 {
+		den, err = strconv.Atoi(args[2])
+	case 2:
 		// ;call <len>
-		var err error
-
 		duration, err = strconv.Atoi(args[1])
-		if err != nil {
-			_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
-			return err
-		}
 	}
 
-	if ok, err := addAwait(m.ChannelID, s, AWAIT_CALL); !ok {
+	return
+}
+
+func cmdCall(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
 		return err
 	}
 
-	channel, err := s.State.Channel(m.ChannelID)
+	if ok, err := checkArgRange(s, m, 0, 3); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	duration, passNum, passDen, err := parseCallArgs(args, PassNumDefault, PassDenDefault)
 	if err != nil {
+		_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
 		return err
 	}
 
+	_, err = startRollCall(s, m.ChannelID, duration, passNum, passDen, "")
+	return err
+}
+
+// Start a roll call vote in the channel on the given motion, which
+// may be empty if the motion was stated outside of the bot. Return
+// the new roll call, or nil if one couldn't be started.
+func startRollCall(s *discordgo.Session, channelID string, duration int,
+	passNum int, passDen int, motion string) (*RollCall, error) {
+
+	// Answers to a quorum call, such as "present", would count as votes.
+	if _, ok := findAwait(channelID, AWAIT_QUORUM_CALL_ID); ok {
+		_, err := s.ChannelMessageSend(channelID, "A quorum call is in progress.")
+		return nil, err
+	}
+
+	// Look the chamber up before claiming the channel, so a failed
+	// lookup doesn't leave an await with no roll call behind it.
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		return nil, err
+	}
+
 	var members []*discordgo.Member
 	members, err = getChamberMembers(s, channel)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	memberIDs := make([]string, len(members))
 
-	for i, member := range members {
-		memberIDs[i] = member.User.ID
+	if ok, err := addAwait(channelID, s, AWAIT_CALL); !ok {
+		return nil, err
 	}
+	// Members on leave are excused from the vote and from quorum.
+	pruneLeaves(channelID)
+	var memberIDs, excused []string
+	users := make(map[string]*discordgo.User)
 
-	// Store roll call data.
-	rollCall := RollCall{
-		votes:       make(map[string]Vote),
-		members:     memberIDs,
-		quorum:      int(math.Floor(float64(len(members))/2.0)) + 1,
-		timerActive: duration > 0,
-		passNum:     passNum,
-		passDen:     passDen,
-		active:      true,
-	}
-	RollCalls[m.ChannelID] = &rollCall
-
-	// Start populating the roll call reply.
-	content := ""
 	for _, member := range members {
-		content += "Mr. " + member.Mention() + "\n"
-	}
-
-	content += "\nYou have "
-	if duration > 0 {
-		content += strconv.Itoa(duration) + " minute"
-		if duration > 1 {
-			content += "s"
+		users[member.User.ID] = member.User
+		if onLeave(channelID, member.User.ID, time.Now()) {
+			excused = append(excused, member.User.ID)
+		} else {
+			memberIDs = append(memberIDs, member.User.ID)
 		}
-	} else {
-		content += "unlimited time"
 	}
 
-	content += " with " + rollCall.PassReqtoa() +
-		" required to vote in the affirmative. The "
-	if duration > 0 {
-		content += "clock is on."
-	} else {
-		content += "vote is on."
-	}
+	// Store roll call data.
+	rollCall := RollCall{
+		channelID:    channelID,
+		guildID:      channel.GuildID,
+		votes:        make(map[string]Vote),
+		voteMessages: make(map[string]string),
+		voteReacts:   make(map[string]map[Vote]bool),
+		members:      memberIDs,
+		excused:      excused,
+		users:        users,
+		quorum:       quorumOf(len(memberIDs)),
+		timerActive:  duration > 0,
+		deadline:     time.Now().Add(time.Duration(duration) * time.Minute),
+		passNum:      passNum,
+		passDen:      passDen,
+		active:       true,
+		motion:       motion,
+		start:        time.Now(),
+	}
+	RollCalls[channelID] = &rollCall
 
 	if rollCall.timerActive {
+		scheduleReminders(s, channelID, &rollCall, duration)
+
 		go func() {
 			time.Sleep(time.Duration(duration) * time.Minute)
 
+			CommandMutex.Lock()
+			defer CommandMutex.Unlock()
+
 			if !rollCall.timerActive {
 				// Roll call has been restarted
 				return
 			}
 
 			rollCall.timerActive = false
+			rollCall.clockStopped = true
+			updateRollCallStatus(s, channelID, &rollCall)
 
 			if rollCall.QuorumMet() {
-				stopRollCall(s, m.ChannelID)
+				stopRollCall(s, channelID)
 			} else {
 				response := "***Quorum is " + strconv.Itoa(rollCall.quorum) +
 					". There are currently " + strconv.Itoa(len(rollCall.votes)) +
 					" votes.***\n*Is there anyone who would like to cast or change a vote?*"
-				s.ChannelMessageSend(m.ChannelID, response)
+				s.ChannelMessageSend(channelID, response)
 			}
 		}()
 	}
 
-	_, err = s.ChannelMessageSend(m.ChannelID, content)
-	return err
+	msg, err := s.ChannelMessageSend(channelID, rollCall.status())
+	if err != nil {
+		return &rollCall, err
+	}
+
+	rollCall.messageID = msg.ID
+	rollCall.lastEdit = time.Now()
+	// Voting by reaction is a convenience; the vote goes on without it.
+	if err := addVoteReacts(s, &rollCall); err != nil {
+		log.Println("Error adding vote reactions:", err)
+	}
+	if rollCall.timerActive {
+		startCountdown(s, channelID, &rollCall)
+	}
+
+	return &rollCall, nil
 }
 
 func awaitCall(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	rollCall := RollCalls[m.ChannelID]
-	var err error
 
 	// Add a vote to the roster if they're a member.
 	if rollCall.isMember(m.Author.ID) {
-		vote, err := parseVote(m.Content)
+		vote, err := parseVote(m.GuildID, m.Content)
 		if err == nil {
-			rollCall.votes[m.Author.ID] = vote
+			if ok, reason := rollCall.recordVote(m.Author.ID, vote, ""); !ok {
+				_, err = s.ChannelMessageSend(m.ChannelID, m.Author.Mention()+" "+reason)
+				return err
+			}
 
-			err = s.MessageReactionAdd(m.ChannelID, m.ID, VOTE_REACTS[vote])
+			rollCall.voteMessages[m.Author.ID] = m.ID
+
+			err = s.MessageReactionAdd(m.ChannelID, m.ID, vocabulary(m.GuildID).words(vote).React)
 			if err != nil {
 				return err
 			}
+			updateRollCallStatus(s, m.ChannelID, rollCall)
 		}
 	}
 
+	return stopOnQuorum(s, rollCall)
+}
+
+// Stop the roll call if it has no clock and has reached quorum.
+func stopOnQuorum(s *discordgo.Session, rollCall *RollCall) error {
 	if !rollCall.timerActive && rollCall.QuorumMet() {
-		_, err = stopRollCall(s, m.ChannelID)
+		_, err := stopRollCall(s, rollCall.channelID)
+		return err
 	}
 
-	return err
+	return nil
 }
 
 func cmdCast(s *discordgo.Session, m *discordgo.MessageCreate) error {
@@ -413,7 +492,7 @@ func cmdCast(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	}
 
 	voteString := args[2]
-	vote, err := parseVote(voteString)
+	vote, err := parseVote(m.GuildID, voteString)
 	if err != nil {
 		_, err := s.ChannelMessageSend(m.ChannelID, "'"+voteString+"' is not a valid vote.")
 		return err
@@ -428,12 +507,25 @@ func cmdCast(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	castee := m.Mentions[0]
 
 	if rollCall.isMember(castee.ID) {
-		rollCall.votes[castee.ID] = vote
-		_, err := s.ChannelMessageSend(m.ChannelID, "Recorded '"+vote.String()+
-			"' for "+castee.Username+".")
+		previous, changed := rollCall.votes[castee.ID]
+		if ok, reason := rollCall.recordVote(castee.ID, vote, m.Author.ID); !ok {
+			_, err := s.ChannelMessageSend(m.ChannelID, reason)
+			return err
+		}
+		// The member's own message no longer gives their vote.
+		delete(rollCall.voteMessages, castee.ID)
+		updateRollCallStatus(s, m.ChannelID, rollCall)
+
+		message := "Recorded '" + vote.String() + "' for " + address(m.ChannelID, castee) + "."
+		if changed && previous != vote {
+			message = "Changed the vote of " + address(m.ChannelID, castee) + " from '" +
+				previous.String() + "' to '" + vote.String() + "'."
+		}
+
+		_, err := s.ChannelMessageSend(m.ChannelID, message)
 		return err
 	} else {
-		_, err := s.ChannelMessageSend(m.ChannelID, castee.Username+" is not a voting member.")
+		_, err := s.ChannelMessageSend(m.ChannelID, address(m.ChannelID, castee)+" is not a voting member.")
 		return err
 	}
 }
@@ -463,9 +555,14 @@ func cmdSetVotes(s *discordgo.Session, m *discordgo.MessageCreate) error {
 		return err
 	}
 
-	rollCall := RollCalls[m.ChannelID]
+	rollCall, ok := RollCalls[m.ChannelID]
+	if !ok {
+		_, err = s.ChannelMessageSend(m.ChannelID, MSG_NO_RECENT_CALL)
+		return err
+	}
 	rollCall.passNum = num
 	rollCall.passDen = den
+	updateRollCallStatus(s, m.ChannelID, rollCall)
 
 	_, err = s.ChannelMessageSend(m.ChannelID,
 		rollCall.PassReqtoa()+" will require to vote in the affirmative to pass the motion.")
@@ -473,12 +570,34 @@ func cmdSetVotes(s *discordgo.Session, m *discordgo.MessageCreate) error {
 }
 
 func cmdGetVotes(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 0, 1); !ok {
+		return err
+	}
+
 	rollCall, ok := RollCalls[m.ChannelID]
 	if !ok {
 		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NO_RECENT_CALL)
 		return err
 	}
 
+	args := strings.Split(m.Content, " ")
+	if len(args) == 2 {
+		if args[1] != "--history" {
+			_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+			return err
+		}
+
+		content, err := rollCall.historyLog(s)
+		if err != nil {
+			return err
+		}
+		if content == "" {
+			content = "No votes have been cast."
+		}
+
+		return sendSplit(s, m.ChannelID, "*Vote history:*\n\n"+content)
+	}
+
 	ayes, nays, absents := rollCall.countVotes()
 	content := "*The Yeas and Nays "
 	if rollCall.active {
@@ -489,16 +608,15 @@ func cmdGetVotes(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	content += fmt.Sprintf("%d - %d with %d absentions:*\n\n", ayes, nays, absents)
 
 	for userID, vote := range rollCall.votes {
-		user, err := s.User(userID)
+		user, err := rollCall.user(s, userID)
 		if err != nil {
 			return err
 		}
 
-		content += user.Username + ": " + vote.String() + "\n"
+		content += address(m.ChannelID, user) + ": " + vote.String() + "\n"
 	}
 
-	_, err := s.ChannelMessageSend(m.ChannelID, content)
-	return err
+	return sendSplit(s, m.ChannelID, content)
 }
 
 func cmdEndVoting(s *discordgo.Session, m *discordgo.MessageCreate) error {
@@ -532,11 +650,14 @@ func cmdResumeVoting(s *discordgo.Session, m *discordgo.MessageCreate) error {
 
 	rollCall.active = true
 	rollCall.timerActive = false
+	rollCall.clockStopped = true
 
 	if ok, err := addAwait(m.ChannelID, s, AWAIT_CALL); !ok {
 		return err
 	}
 
+	updateRollCallStatus(s, m.ChannelID, rollCall)
+
 	_, err := s.ChannelMessageSend(m.ChannelID, MSG_CALL_RESUMED)
 	return err
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ROUTE_EXECUTIVE = "executive"
+	ROUTE_NONE      = "none"
+
+	STATUS_REFERRED = "referred"
+)
+
+var (
+	CMD_ROUTE = Command{
+		Handler: cmdRoute,
+		Summary: "Show or set where items passed in this chamber go next: another chamber, " +
+			"the executive, or nowhere",
+		Usage: "[#channel|executive|none]",
+	}
+)
+
+// Return the channel ID in a channel mention such as <#id>.
+func mentionedChannel(arg string) (string, bool) {
+	if !strings.HasPrefix(arg, "<#") || !strings.HasSuffix(arg, ">") {
+		return "", false
+	}
+
+	return arg[2 : len(arg)-1], true
+}
+
+// Describe where a chamber sends the items it passes.
+func routeName(passTo string) string {
+	switch passTo {
+	case "":
+		return "nowhere"
+	case ROUTE_EXECUTIVE:
+		return "the executive"
+	default:
+		return "<#" + passTo + ">"
+	}
+}
+
+// Send an item passed in the chamber on to the next chamber or the
+// executive, if the chamber routes its items anywhere.
+func routeItem(s *discordgo.Session, m *discordgo.MessageCreate, identifier string) error {
+	passTo := Chambers[m.ChannelID].PassTo
+	if passTo == "" {
+		return nil
+	}
+
+	destination, status := ROUTE_EXECUTIVE, STATUS_PRESENTED
+	channelID := Executive.Channel
+	if passTo != ROUTE_EXECUTIVE {
+		chamber, ok := Chambers[passTo]
+		if !ok {
+			_, err := s.ChannelMessageSend(m.ChannelID, "This chamber refers items to "+routeName(passTo)+
+				", which is no longer a chamber.")
+			return err
+		}
+		destination, status = chamber.ApiName, STATUS_REFERRED
+		channelID = passTo
+	}
+
+	item, err := fetchDocketItem(s, m, identifier)
+	if err != nil {
+		return err
+	}
+
+	// The destination may docket the item under a new identifier.
+	var docket Docket
+	if err := apiRequest(s, m, "docket/refer", url.Values{
+		"identifier": {identifier},
+		"chamber":    {destination},
+		"status":     {status},
+	}, &docket); err != nil {
+		return err
+	}
+
+	referred, current := identifier, identifier
+	if docket.Identifier != "" && docket.Identifier != identifier {
+		referred = docket.Identifier + " (formerly " + identifier + ")"
+		current = docket.Identifier
+	}
+
+	if passTo == ROUTE_EXECUTIVE {
+		if err := presentToExecutive(s, current, m.ChannelID); err != nil {
+			return err
+		}
+	} else if err := addToAgenda(passTo, AgendaItem{
+		Identifier: current,
+		From:       m.ChannelID,
+		Added:      time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	if channelID != "" {
+		notice := "__" + referred + "__ *(" + item.Name + ")* has passed <#" + m.ChannelID + "> and is "
+		if passTo == ROUTE_EXECUTIVE {
+			notice += "presented to the executive."
+		} else {
+			notice += "referred here."
+		}
+
+		if _, err := s.ChannelMessageSend(channelID, notice); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, identifier+" is sent on to "+routeName(passTo)+".")
+	return err
+}
+
+func cmdRoute(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 0, 1); !ok {
+		return err
+	}
+
+	chamber, ok := Chambers[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	if len(args) == 1 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "Items passed here go to "+routeName(chamber.PassTo)+".")
+		return err
+	}
+
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	switch args[1] {
+	case ROUTE_NONE:
+		chamber.PassTo = ""
+	case ROUTE_EXECUTIVE:
+		chamber.PassTo = ROUTE_EXECUTIVE
+	default:
+		channelID, ok := mentionedChannel(args[1])
+		if !ok || !isChamber(channelID) || channelID == m.ChannelID {
+			_, err := s.ChannelMessageSend(m.ChannelID, "Items can go to another chamber, "+
+				"the executive, or none.")
+			return err
+		}
+		chamber.PassTo = channelID
+	}
+
+	Chambers[m.ChannelID] = chamber
+	if err := saveChambers(); err != nil {
+		return err
+	}
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"github.com/bwmarrin/discordgo"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	RULES_DIR = "rules"
+
+	RULING_SUSTAINED = "sustained"
+	RULING_OVERRULED = "overruled"
+
+	RULE_TEXT_MAX    = 1500 // Characters of a rule's text to quote
+	RULE_SEARCH_MAX  = 10   // Search results to list
+	RULE_SNIPPET_LEN = 80
+)
+
+var (
+	CMD_RULE = Command{
+		Handler: cmdRule,
+		Summary: "Cite a rule from this chamber's rules book by number",
+		Usage:   "<number>",
+	}
+	CMD_RULES = Command{
+		Handler: cmdRules,
+		Summary: "List the rules in this chamber's rules book, search them, or reload the book",
+		Usage:   "[search <text>|reload]",
+	}
+	CMD_POINT_OF_ORDER = Command{
+		Handler: cmdPointOfOrder,
+		Summary: "Raise a point of order under one of this chamber's rules",
+		Usage:   "<rule> [text]",
+	}
+	CMD_RULING = Command{
+		Handler: cmdRuling,
+		Summary: "Rule on the point of order before the chair",
+		Usage:   "<sustained|overruled> [reason]",
+	}
+
+	// Headings such as "## 12.3 Limits", "# Rule 12: Debate" or
+	// "### §4.1. Quorum" start a numbered rule.
+	RULE_HEADING = regexp.MustCompile(`^#{1,6}\s+(?:(?i:rule|section)\s+|§\s*)?(\d+(?:\.\d+)*)\.?\s*[:\-]?\s*(.*)$`)
+	// References such as "Rule 4", "section 12.3" or "§4.1" in a rule's
+	// text.
+	RULE_REFERENCE = regexp.MustCompile(`(?i)(?:\brules?|\bsections?|§)\s*(\d+(?:\.\d+)*)`)
+)
+
+type Rule struct {
+	Number     string
+	Title      string
+	Text       string
+	References []string // Numbers of the rules the text refers to
+}
+
+// A chamber's rules, in the order the book gives them.
+type RuleBook struct {
+	Rules []Rule
+	index map[string]int
+}
+
+// A point of order awaiting the chair's ruling.
+type PointOfOrder struct {
+	UserID string
+	Rule   string
+	Text   string
+}
+
+// State
+var RuleBooks = make(map[string]RuleBook)
+var PointsOfOrder = make(map[string]PointOfOrder)
+
+// Return the path of the chamber's Markdown rules book.
+func rulesPath(channelID string) string {
+	if path := Chambers[channelID].Rules; path != "" {
+		return path
+	}
+
+	return filepath.Join(RULES_DIR, channelID+".md")
+}
+
+// Parse a Markdown rules book. Each numbered heading starts a rule, and
+// everything up to the next numbered heading is its text.
+func parseRuleBook(path string) (RuleBook, error) {
+	book := RuleBook{index: make(map[string]int)}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return book, err
+	}
+
+	// The rule the lines being read belong to, or -1 before the first
+	// rule and under a repeated number.
+	current := -1
+	var text []string
+	finish := func() {
+		if current < 0 {
+			return
+		}
+
+		rule := &book.Rules[current]
+		rule.Text = strings.TrimSpace(strings.Join(text, "\n"))
+		for _, match := range RULE_REFERENCE.FindAllStringSubmatch(rule.Text, -1) {
+			if match[1] != rule.Number && !containsString(rule.References, match[1]) {
+				rule.References = append(rule.References, match[1])
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		match := RULE_HEADING.FindStringSubmatch(line)
+		if match == nil {
+			text = append(text, line)
+			continue
+		}
+
+		finish()
+		text = nil
+		if _, ok := book.index[match[1]]; ok {
+			log.Println("Rule " + match[1] + " appears twice in " + path + "; using the first")
+			current = -1
+			continue
+		}
+		current = len(book.Rules)
+		book.index[match[1]] = current
+		book.Rules = append(book.Rules, Rule{Number: match[1], Title: strings.TrimSpace(match[2])})
+	}
+	finish()
+
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return book, err
+	}
+
+	return book, file.Close()
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Load the rules book of every chamber that has one.
+func loadRuleBooks() error {
+	for channelID := range Chambers {
+		if err := loadRuleBook(channelID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadRuleBook(channelID string) error {
+	book, err := parseRuleBook(rulesPath(channelID))
+	if os.IsNotExist(err) {
+		delete(RuleBooks, channelID)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	RuleBooks[channelID] = book
+	return nil
+}
+
+// Return the rule with the number, which may be written like "12.3",
+// "§12.3" or "12.3.".
+func (b RuleBook) rule(number string) (Rule, bool) {
+	number = strings.TrimSuffix(strings.TrimPrefix(number, "§"), ".")
+	i, ok := b.index[number]
+	if !ok {
+		return Rule{}, false
+	}
+
+	return b.Rules[i], true
+}
+
+// Return the rules numbered directly beneath the rule, e.g. 12.1 and
+// 12.2 for 12.
+func (b RuleBook) sections(number string) []Rule {
+	var sections []Rule
+	for _, rule := range b.Rules {
+		if strings.HasPrefix(rule.Number, number+".") &&
+			!strings.Contains(rule.Number[len(number)+1:], ".") {
+			sections = append(sections, rule)
+		}
+	}
+
+	return sections
+}
+
+// Return the rules whose number, title or text contain the query.
+func (b RuleBook) search(query string) []Rule {
+	query = strings.ToLower(query)
+
+	var found []Rule
+	for _, rule := range b.Rules {
+		if strings.Contains(strings.ToLower(rule.Number+" "+rule.Title+"\n"+rule.Text), query) {
+			found = append(found, rule)
+		}
+	}
+
+	return found
+}
+
+func (r Rule) heading() string {
+	if r.Title == "" {
+		return "Rule " + r.Number
+	}
+
+	return "Rule " + r.Number + ": " + r.Title
+}
+
+// Return the rule's text, shortened to at most max characters.
+func (r Rule) excerpt(max int) string {
+	text := []rune(r.Text)
+	if len(text) <= max {
+		return r.Text
+	}
+
+	return strings.TrimSpace(string(text[:max])) + "…"
+}
+
+// Return the chamber's rules book, and send a message if it has none.
+func checkRuleBook(s *discordgo.Session, m *discordgo.MessageCreate) (RuleBook, bool, error) {
+	if !isChamber(m.ChannelID) {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return RuleBook{}, false, err
+	}
+
+	book, ok := RuleBooks[m.ChannelID]
+	if !ok || len(book.Rules) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "This chamber has no rules book.")
+		return RuleBook{}, false, err
+	}
+
+	return book, true, nil
+}
+
+// Return the numbered rule, and send a message if the book has none.
+func checkRule(s *discordgo.Session, m *discordgo.MessageCreate, book RuleBook, number string) (Rule, bool, error) {
+	rule, ok := book.rule(number)
+	if ok {
+		return rule, true, nil
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, "There's no rule "+number+". Use `"+PREFIX+
+		"rules` to list them.")
+	return Rule{}, false, err
+}
+
+func cmdRule(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	book, ok, err := checkRuleBook(s, m)
+	if !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	rule, ok, err := checkRule(s, m, book, args[1])
+	if !ok {
+		return err
+	}
+
+	response := "**" + rule.heading() + "**"
+	if rule.Text != "" {
+		response += "\n\n" + rule.excerpt(RULE_TEXT_MAX)
+	}
+
+	if sections := book.sections(rule.Number); len(sections) > 0 {
+		var numbers []string
+		for _, section := range sections {
+			numbers = append(numbers, section.Number)
+		}
+		response += "\n\n**Sections:** " + strings.Join(numbers, ", ")
+	}
+
+	var references []string
+	for _, number := range rule.References {
+		if referenced, ok := book.rule(number); ok {
+			references = append(references, referenced.heading())
+		}
+	}
+	if len(references) > 0 {
+		response += "\n**See also:** " + strings.Join(references, "; ")
+	}
+
+	// The text, sections and references together can run past one
+	// message.
+	return sendSplit(s, m.ChannelID, response)
+}
+
+func cmdRules(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 0, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	if len(args) == 2 && args[1] == "reload" {
+		if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+			return err
+		}
+
+		if !isChamber(m.ChannelID) {
+			_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+			return err
+		}
+
+		if err := loadRuleBook(m.ChannelID); err != nil {
+			_, err = s.ChannelMessageSend(m.ChannelID, "Couldn't read the rules book: "+err.Error())
+			return err
+		}
+
+		err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+		return err
+	}
+
+	book, ok, err := checkRuleBook(s, m)
+	if !ok {
+		return err
+	}
+
+	if len(args) == 1 {
+		response := "*Rules:*\n"
+		for _, rule := range book.Rules {
+			// Only list top-level rules; ;rule shows their sections.
+			if !strings.Contains(rule.Number, ".") {
+				response += "\n" + rule.heading()
+			}
+		}
+
+		return sendSplit(s, m.ChannelID, response)
+	}
+
+	if args[1] != "search" || len(args) < 3 {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	query := strings.Join(args[2:], " ")
+	found := book.search(query)
+	if len(found) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "No rules mention '"+query+"'.")
+		return err
+	}
+
+	response := "*Rules mentioning '" + query + "':*\n"
+	for i, rule := range found {
+		if i == RULE_SEARCH_MAX {
+			response += "\n…and " + strconv.Itoa(len(found)-RULE_SEARCH_MAX) + " more."
+			break
+		}
+
+		response += "\n**" + rule.heading() + "**"
+		if rule.Text != "" {
+			response += " " + strings.Replace(rule.excerpt(RULE_SNIPPET_LEN), "\n", " ", -1)
+		}
+	}
+
+	return sendSplit(s, m.ChannelID, response)
+}
+
+func cmdPointOfOrder(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsMember(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	book, ok, err := checkRuleBook(s, m)
+	if !ok {
+		return err
+	}
+
+	if point, ok := PointsOfOrder[m.ChannelID]; ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "A point of order from <@"+point.UserID+
+			"> is already before the chair.")
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	rule, ok, err := checkRule(s, m, book, args[1])
+	if !ok {
+		return err
+	}
+
+	point := PointOfOrder{
+		UserID: m.Author.ID,
+		Rule:   rule.Number,
+		Text:   strings.Join(args[2:], " "),
+	}
+	PointsOfOrder[m.ChannelID] = point
+
+	message := "**Point of order** from <@" + point.UserID + "> under " + rule.heading() + "."
+	if point.Text != "" {
+		message += "\n" + point.Text
+	}
+	if rule.Text != "" {
+		message += "\n\n> " + strings.Replace(rule.excerpt(RULE_TEXT_MAX), "\n", "\n> ", -1)
+	}
+	message += "\n\nThe chair will rule with `" + PREFIX + "ruling sustained` or `" + PREFIX +
+		"ruling overruled`."
+
+	return sendSplit(s, m.ChannelID, message)
+}
+
+func cmdRuling(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsSpeaker(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	point, ok := PointsOfOrder[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "No point of order is before the chair.")
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	ruling := strings.ToLower(args[1])
+	if ruling != RULING_SUSTAINED && ruling != RULING_OVERRULED {
+		_, err := s.ChannelMessageSend(m.ChannelID, "The chair can rule the point sustained or overruled.")
+		return err
+	}
+	delete(PointsOfOrder, m.ChannelID)
+
+	message := "The point of order from <@" + point.UserID + "> under Rule " + point.Rule +
+		" is **" + ruling + "**."
+	if reason := strings.Join(args[2:], " "); reason != "" {
+		message += "\n" + reason
+	}
+
+	return sendSplit(s, m.ChannelID, message)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"net/url"
+	"strings"
+)
+
+var (
+	CMD_COSPONSOR = Command{
+		Handler: cmdCosponsor,
+		Summary: "Add yourself as a cosponsor of a docketed item",
+		Usage:   "<MOTION>",
+	}
+)
+
+// A sponsor of a docketed item. The name is cached when the item is
+// submitted, since usernames change.
+type Sponsor struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func sponsorOf(user *discordgo.User) Sponsor {
+	return Sponsor{ID: user.ID, Name: displayName(user)}
+}
+
+// Return the user mentioned by an argument such as <@id> or <@!id>.
+func mentionedUser(m *discordgo.MessageCreate, arg string) (*discordgo.User, bool) {
+	if !strings.HasPrefix(arg, "<@") || !strings.HasSuffix(arg, ">") {
+		return nil, false
+	}
+	id := strings.TrimPrefix(strings.TrimSuffix(arg[2:], ">"), "!")
+
+	for _, user := range m.Mentions {
+		if user.ID == id {
+			return user, true
+		}
+	}
+
+	return nil, false
+}
+
+// Return the sponsors mentioned by the arguments, in order and without
+// duplicates.
+func mentionedSponsors(m *discordgo.MessageCreate, args []string) ([]Sponsor, bool) {
+	var sponsors []Sponsor
+	seen := make(map[string]bool)
+
+	for _, arg := range args {
+		user, ok := mentionedUser(m, arg)
+		if !ok {
+			return nil, false
+		}
+
+		if !seen[user.ID] {
+			seen[user.ID] = true
+			sponsors = append(sponsors, sponsorOf(user))
+		}
+	}
+
+	return sponsors, true
+}
+
+// Return the names and IDs of the sponsors as API parameters.
+func sponsorParams(sponsors []Sponsor) (names []string, ids []string) {
+	for _, sponsor := range sponsors {
+		names = append(names, sponsor.Name)
+		ids = append(ids, sponsor.ID)
+	}
+
+	return names, ids
+}
+
+// Return the names of the sponsors as a list.
+func sponsorNames(sponsors []Sponsor) string {
+	names, _ := sponsorParams(sponsors)
+	return strings.Join(names, ", ")
+}
+
+// Return whether the user sponsors or cosponsors the item.
+func (item DocketItem) sponsoredBy(userID string) bool {
+	if item.SponsorID == userID {
+		return true
+	}
+
+	for _, cosponsor := range item.Cosponsors {
+		if cosponsor.ID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cmdCosponsor(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorIsMember(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 1, 1); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	identifier := args[1]
+
+	item, err := fetchDocketItem(s, m, identifier)
+	if err != nil {
+		return err
+	}
+
+	if item.sponsoredBy(m.Author.ID) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "You already sponsor "+identifier+".")
+		return err
+	}
+
+	cosponsor := sponsorOf(m.Author)
+	if err := apiRequest(s, m, "docket/cosponsor", url.Values{
+		"identifier": {identifier},
+		"id":         {cosponsor.ID},
+		"name":       {cosponsor.Name},
+	}, nil); err != nil {
+		return err
+	}
+
+	err = s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
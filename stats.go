@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	CMD_STATS = Command{
+		Handler: cmdStats,
+		Summary: "Show members' voting records from archived roll calls, optionally as a CSV file. " +
+			"Periods look like 30d, 4w or 12h",
+		Usage: "[csv] [member] [period]",
+	}
+)
+
+type MemberStats struct {
+	userID       string
+	eligible     int // Roll calls the member could vote in
+	cast         int
+	missed       int
+	abstained    int
+	majority     int // Votes cast when one side had a majority
+	withMajority int
+	decided      int // Yea or nay votes cast
+	withWinner   int
+}
+
+// Return part as a percentage of whole.
+func percent(part int, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+
+	return 100 * float64(part) / float64(whole)
+}
+
+func (m *MemberStats) abstentionRate() float64 {
+	return percent(m.abstained, m.cast)
+}
+
+func (m *MemberStats) majorityRate() float64 {
+	return percent(m.withMajority, m.majority)
+}
+
+func (m *MemberStats) winnerRate() float64 {
+	return percent(m.withWinner, m.decided)
+}
+
+// Parse a period such as 30d, 4w or 12h.
+func parsePeriod(period string) (time.Duration, error) {
+	if strings.HasSuffix(period, "d") || strings.HasSuffix(period, "w") {
+		n, err := strconv.Atoi(period[:len(period)-1])
+		if err != nil {
+			return 0, err
+		}
+
+		days := n
+		if strings.HasSuffix(period, "w") {
+			days *= 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(period)
+}
+
+// Tally the voting records of every member in the chamber's archived
+// roll calls ending after since.
+func memberStats(channelID string, since time.Time) map[string]*MemberStats {
+	stats := make(map[string]*MemberStats)
+
+	for _, record := range Archive[channelID] {
+		if record.End.Before(since) {
+			continue
+		}
+
+		// Find the side with the majority, if any, and the winning side.
+		var ayes, nays int
+		for _, vote := range record.Votes {
+			if vote == For {
+				ayes++
+			} else if vote == Against {
+				nays++
+			}
+		}
+
+		majority := Abstained
+		if ayes > nays {
+			majority = For
+		} else if nays > ayes {
+			majority = Against
+		}
+
+		winner := Against
+		if record.Passed {
+			winner = For
+		}
+
+		for _, userID := range record.Members {
+			member, ok := stats[userID]
+			if !ok {
+				member = &MemberStats{userID: userID}
+				stats[userID] = member
+			}
+			member.eligible++
+
+			vote, voted := record.Votes[userID]
+			if !voted {
+				member.missed++
+				continue
+			}
+
+			member.cast++
+			if vote == Abstained {
+				member.abstained++
+				continue
+			}
+
+			if majority != Abstained {
+				member.majority++
+				if vote == majority {
+					member.withMajority++
+				}
+			}
+
+			member.decided++
+			if vote == winner {
+				member.withWinner++
+			}
+		}
+	}
+
+	return stats
+}
+
+// Write the stats as CSV, one member per row.
+func statsCSV(s *discordgo.Session, stats []*MemberStats) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"user_id", "name", "eligible", "cast", "missed", "abstained",
+		"abstention_rate", "majority_agreement", "winning_side"})
+	for _, member := range stats {
+		user, err := s.User(member.userID)
+		if err != nil {
+			return nil, err
+		}
+
+		w.Write([]string{
+			member.userID,
+			displayName(user),
+			strconv.Itoa(member.eligible),
+			strconv.Itoa(member.cast),
+			strconv.Itoa(member.missed),
+			strconv.Itoa(member.abstained),
+			fmt.Sprintf("%.1f", member.abstentionRate()),
+			fmt.Sprintf("%.1f", member.majorityRate()),
+			fmt.Sprintf("%.1f", member.winnerRate()),
+		})
+	}
+
+	w.Flush()
+	return &buf, w.Error()
+}
+
+func cmdStats(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 0, 3); !ok {
+		return err
+	}
+
+	if !isChamber(m.ChannelID) {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	var (
+		args     = strings.Split(m.Content, " ")
+		since    time.Time
+		asCSV    bool
+		memberID string
+	)
+
+	for _, arg := range args[1:] {
+		if arg == "csv" {
+			asCSV = true
+		} else if strings.HasPrefix(arg, "<@") {
+			if len(m.Mentions) != 1 {
+				_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+				return err
+			}
+			memberID = m.Mentions[0].ID
+		} else {
+			period, err := parsePeriod(arg)
+			if err != nil || period <= 0 {
+				_, err = s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+				return err
+			}
+			since = time.Now().Add(-period)
+		}
+	}
+
+	allStats := memberStats(m.ChannelID, since)
+	var stats []*MemberStats
+	if memberID != "" {
+		member, ok := allStats[memberID]
+		if !ok {
+			_, err := s.ChannelMessageSend(m.ChannelID, "That member hasn't been in any recorded roll calls.")
+			return err
+		}
+		stats = append(stats, member)
+	} else {
+		for _, member := range allStats {
+			stats = append(stats, member)
+		}
+	}
+
+	if len(stats) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "No roll calls have been recorded for that period.")
+		return err
+	}
+
+	// Members who miss the most votes first.
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].missed != stats[j].missed {
+			return stats[i].missed > stats[j].missed
+		}
+		return stats[i].userID < stats[j].userID
+	})
+
+	if asCSV {
+		buf, err := statsCSV(s, stats)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.ChannelFileSend(m.ChannelID, "stats.csv", buf)
+		return err
+	}
+
+	response := "*Voting records:*\n"
+	for _, member := range stats {
+		user, err := s.User(member.userID)
+		if err != nil {
+			return err
+		}
+
+		response += fmt.Sprintf("\n**%s**: %d of %d votes cast, %d missed, "+
+			"%.0f%% abstained, %.0f%% with the majority, %.0f%% with the winning side",
+			address(m.ChannelID, user), member.cast, member.eligible, member.missed,
+			member.abstentionRate(), member.majorityRate(), member.winnerRate())
+	}
+
+	// Large chambers need more than one message.
+	return sendSplit(s, m.ChannelID, response)
+}
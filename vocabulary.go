@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	CMD_VOTEWORDS = Command{
+		Handler: cmdVoteWords,
+		Summary: "List the words accepted as votes in this server",
+	}
+	CMD_SETVOTEWORDS = Command{
+		Handler: cmdSetVoteWords,
+		Summary: "Set the words accepted for a kind of vote in this server",
+		Usage:   "<for|against|abstained> <word> ...",
+	}
+	CMD_SETVOTEREACT = Command{
+		Handler: cmdSetVoteReact,
+		Summary: "Set the reaction given to a kind of vote in this server",
+		Usage:   "<for|against|abstained> <emoji>",
+	}
+
+	DEFAULT_VOCABULARY = Vocabulary{
+		For: VoteWords{
+			Words: []string{"aye", "yea", "yes"},
+			React: "\u2705",
+		},
+		Against: VoteWords{
+			Words: []string{"nay", "no"},
+			React: "\u274c",
+		},
+		Abstained: VoteWords{
+			Words: []string{"present", "abstain"},
+			React: "\u2796",
+		},
+	}
+)
+
+// The words that count as a kind of vote, and the reaction given to
+// acknowledge it.
+type VoteWords struct {
+	Words []string `json:"words"`
+	React string   `json:"react"`
+}
+
+// A server's vote vocabulary. Anything left empty falls back to
+// DEFAULT_VOCABULARY.
+type Vocabulary struct {
+	For       VoteWords `json:"for"`
+	Against   VoteWords `json:"against"`
+	Abstained VoteWords `json:"abstained"`
+}
+
+func saveVocabularies() error {
+	file, err := os.Create(VOCAB_PATH)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	enc.Encode(Vocabularies)
+
+	return file.Close()
+}
+
+// Return the vote vocabulary of the guild.
+func vocabulary(guildID string) Vocabulary {
+	if vocab, ok := Vocabularies[guildID]; ok {
+		return vocab
+	}
+
+	return DEFAULT_VOCABULARY
+}
+
+// Return the words and reaction for the vote, falling back to the
+// defaults for anything that isn't set.
+func (v Vocabulary) words(vote Vote) VoteWords {
+	var words, defaults VoteWords
+
+	switch vote {
+	case For:
+		words, defaults = v.For, DEFAULT_VOCABULARY.For
+	case Against:
+		words, defaults = v.Against, DEFAULT_VOCABULARY.Against
+	case Abstained:
+		words, defaults = v.Abstained, DEFAULT_VOCABULARY.Abstained
+	}
+
+	if len(words.Words) == 0 {
+		words.Words = defaults.Words
+	}
+	if words.React == "" {
+		words.React = defaults.React
+	}
+
+	return words
+}
+
+// Replace the words and reaction for the vote.
+func (v *Vocabulary) setWords(vote Vote, words VoteWords) {
+	switch vote {
+	case For:
+		v.For = words
+	case Against:
+		v.Against = words
+	case Abstained:
+		v.Abstained = words
+	}
+}
+
+// Return the vote named by a string such as "for" or "against".
+func parseVoteName(name string) (Vote, bool) {
+	for _, vote := range []Vote{For, Against, Abstained} {
+		if strings.EqualFold(name, vote.String()) {
+			return vote, true
+		}
+	}
+
+	return -1, false
+}
+
+// Interprets a string content and gives the corresponding vote in the
+// guild's vocabulary, going by its first word. If the first word isn't
+// a vote, it instead returns strconv.ErrSyntax and -1 as a vote.
+func parseVote(guildID string, content string) (Vote, error) {
+	fields := strings.Fields(strings.ToLower(content))
+	if len(fields) == 0 {
+		return -1, strconv.ErrSyntax
+	}
+
+	word := strings.TrimRightFunc(fields[0], unicode.IsPunct)
+	vocab := vocabulary(guildID)
+
+	for _, vote := range []Vote{For, Against, Abstained} {
+		for _, v := range vocab.words(vote).Words {
+			if word == v {
+				return vote, nil
+			}
+		}
+	}
+
+	return -1, strconv.ErrSyntax
+}
+
+func cmdVoteWords(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	vocab := vocabulary(m.GuildID)
+
+	response := "*Accepted votes:*\n"
+	for _, vote := range []Vote{For, Against, Abstained} {
+		words := vocab.words(vote)
+		response += "\n" + words.React + " **" + vote.String() + ":** `" +
+			strings.Join(words.Words, "`, `") + "`"
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, response)
+	return err
+}
+
+func cmdSetVoteWords(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 2, ARGS_NO_LIMIT); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	vote, ok := parseVoteName(args[1])
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	vocab := vocabulary(m.GuildID)
+	var words []string
+	for _, word := range args[2:] {
+		word = strings.ToLower(word)
+
+		// Don't let a word count as two kinds of vote.
+		for _, other := range []Vote{For, Against, Abstained} {
+			if other == vote {
+				continue
+			}
+
+			for _, v := range vocab.words(other).Words {
+				if v == word {
+					_, err := s.ChannelMessageSend(m.ChannelID, "'"+word+"' already counts as "+
+						other.String()+".")
+					return err
+				}
+			}
+		}
+
+		words = append(words, word)
+	}
+
+	voteWords := vocab.words(vote)
+	voteWords.Words = words
+	vocab.setWords(vote, voteWords)
+	Vocabularies[m.GuildID] = vocab
+
+	if err := saveVocabularies(); err != nil {
+		return err
+	}
+
+	err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+	return err
+}
+
+func cmdSetVoteReact(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	if ok, err := checkArgRange(s, m, 2, 2); !ok {
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	vote, ok := parseVoteName(args[1])
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+		return err
+	}
+
+	// Custom emojis are reacted with as name:id.
+	react := strings.TrimSuffix(strings.TrimPrefix(args[2], "<"), ">")
+	react = strings.TrimPrefix(react, "a:")
+
+	// Don't let a reaction count as two kinds of vote.
+	vocab := vocabulary(m.GuildID)
+	for _, other := range []Vote{For, Against, Abstained} {
+		if other != vote && vocab.words(other).React == react {
+			_, err := s.ChannelMessageSend(m.ChannelID, "That reaction already counts as "+
+				other.String()+".")
+			return err
+		}
+	}
+
+	// Make sure the emoji is usable before saving it.
+	if err := s.MessageReactionAdd(m.ChannelID, m.ID, react); err != nil {
+		_, err = s.ChannelMessageSend(m.ChannelID, "I can't react with that.")
+		return err
+	}
+
+	voteWords := vocab.words(vote)
+	voteWords.React = react
+	vocab.setWords(vote, voteWords)
+	Vocabularies[m.GuildID] = vocab
+
+	return saveVocabularies()
+}
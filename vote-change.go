@@ -0,0 +1,208 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"strings"
+	"time"
+)
+
+const (
+	VOTE_CHANGE_ANY   = "any"   // Votes may be changed freely
+	VOTE_CHANGE_ONCE  = "once"  // Each member may change their vote once
+	VOTE_CHANGE_CLOCK = "clock" // Votes may be changed until the clock stops
+	VOTE_CHANGE_NEVER = "never" // Votes may not be changed
+
+	DEFAULT_VOTE_CHANGE = VOTE_CHANGE_ANY
+)
+
+var (
+	CMD_VOTECHANGE = Command{
+		Handler: cmdVoteChange,
+		Summary: "Show or set whether members may change their votes in a roll call",
+		Usage:   "[any|once|clock|never]",
+	}
+
+	VOTE_CHANGE_POLICIES = []string{
+		VOTE_CHANGE_ANY,
+		VOTE_CHANGE_ONCE,
+		VOTE_CHANGE_CLOCK,
+		VOTE_CHANGE_NEVER,
+	}
+)
+
+// An entry in a roll call's log of votes.
+type VoteChange struct {
+	userID    string
+	vote      Vote
+	previous  Vote
+	changed   bool   // Whether the member already had a vote
+	retracted bool   // Whether the member withdrew their vote instead
+	castBy    string // UserID of the Speaker who cast the vote, if not the member
+	time      time.Time
+}
+
+// Return the chamber's vote change policy.
+func voteChangePolicy(channelID string) string {
+	if policy := Chambers[channelID].VoteChange; policy != "" {
+		return policy
+	}
+
+	return DEFAULT_VOTE_CHANGE
+}
+
+// Return whether the chamber's vote change policy lets the member
+// change the vote they've already cast, or why it doesn't.
+func (r *RollCall) canChangeVote(userID string) (bool, string) {
+	switch voteChangePolicy(r.channelID) {
+	case VOTE_CHANGE_ONCE:
+		for _, entry := range r.history {
+			if entry.userID == userID && entry.changed {
+				return false, "Votes may only be changed once."
+			}
+		}
+	case VOTE_CHANGE_CLOCK:
+		if r.clockStopped {
+			return false, "Votes may not be changed after the clock stops."
+		}
+	case VOTE_CHANGE_NEVER:
+		return false, "Votes may not be changed."
+	}
+
+	return true, ""
+}
+
+// Record a member's vote if the chamber's vote change policy allows
+// it. castBy is the UserID of whoever cast the vote on the member's
+// behalf, or empty if the member voted themselves. Return whether the
+// vote was recorded, or why it wasn't.
+func (r *RollCall) recordVote(userID string, vote Vote, castBy string) (bool, string) {
+	previous, changed := r.votes[userID]
+	if changed && previous == vote {
+		// Nothing to change.
+		return true, ""
+	}
+
+	if changed {
+		if ok, reason := r.canChangeVote(userID); !ok {
+			return false, reason
+		}
+	}
+
+	r.votes[userID] = vote
+	r.history = append(r.history, VoteChange{
+		userID:   userID,
+		vote:     vote,
+		previous: previous,
+		changed:  changed,
+		castBy:   castBy,
+		time:     time.Now(),
+	})
+
+	return true, ""
+}
+
+// Withdraw a member's vote if the chamber's vote change policy allows
+// it, which counts as changing it. Return whether the vote was
+// withdrawn, or why it wasn't.
+func (r *RollCall) retractVote(userID string) (bool, string) {
+	previous, voted := r.votes[userID]
+	if !voted {
+		return true, ""
+	}
+
+	if ok, reason := r.canChangeVote(userID); !ok {
+		return false, reason
+	}
+
+	delete(r.votes, userID)
+	r.history = append(r.history, VoteChange{
+		userID:    userID,
+		vote:      -1,
+		previous:  previous,
+		changed:   true,
+		retracted: true,
+		time:      time.Now(),
+	})
+
+	return true, ""
+}
+
+// Return the log of votes in the roll call, one per line.
+func (r *RollCall) historyLog(s *discordgo.Session) (string, error) {
+	name := func(userID string) (string, error) {
+		user, err := r.user(s, userID)
+		if err != nil {
+			return "", err
+		}
+
+		return address(r.channelID, user), nil
+	}
+
+	content := ""
+	for _, entry := range r.history {
+		voter, err := name(entry.userID)
+		if err != nil {
+			return "", err
+		}
+
+		content += "`" + entry.time.UTC().Format("15:04:05") + "` " + voter + ": "
+		if entry.changed {
+			content += entry.previous.String() + " → "
+		}
+		if entry.retracted {
+			content += "*withdrawn*"
+		} else {
+			content += entry.vote.String()
+		}
+
+		if entry.castBy != "" {
+			caster, err := name(entry.castBy)
+			if err != nil {
+				return "", err
+			}
+			content += " *(cast by " + caster + ")*"
+		}
+		content += "\n"
+	}
+
+	return content, nil
+}
+
+func cmdVoteChange(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if ok, err := checkArgRange(s, m, 0, 1); !ok {
+		return err
+	}
+
+	chamber, ok := Chambers[m.ChannelID]
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, MSG_NOT_A_CHAMBER)
+		return err
+	}
+
+	args := strings.Split(m.Content, " ")
+	if len(args) == 1 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "The vote change policy is `"+
+			voteChangePolicy(m.ChannelID)+"`.")
+		return err
+	}
+
+	if ok, err := checkAuthorCanManageChannels(s, m); !ok {
+		return err
+	}
+
+	for _, policy := range VOTE_CHANGE_POLICIES {
+		if args[1] == policy {
+			chamber.VoteChange = policy
+			Chambers[m.ChannelID] = chamber
+			if err := saveChambers(); err != nil {
+				return err
+			}
+
+			err := s.MessageReactionAdd(m.ChannelID, m.ID, REACT_OK)
+			return err
+		}
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, MSG_BAD_ARGS)
+	return err
+}